@@ -0,0 +1,267 @@
+package pool
+
+import (
+	"math/bits"
+	"sort"
+	"sync/atomic"
+)
+
+const (
+	histSub    = 8 // buckets per power of two
+	histMinExp = 4 // the first bucket holds sizes up to 1<<histMinExp
+
+	// The last bucket holds every size beyond 1<<(histMaxExp-1). Its
+	// bound must stay positive on 32-bit platforms too.
+	histMaxExp  = min(40, bits.UintSize-2)
+	histBuckets = 1 + (histMaxExp-histMinExp)*histSub
+)
+
+// histIndex returns the bucket of size. Buckets split every power of two in
+// histSub steps, so a bucket bound is at most 1/histSub above its sizes.
+func histIndex(size int) int {
+	if size <= 1<<histMinExp {
+		return 0
+	}
+	e := bits.Len(uint(size - 1)) // 1<<(e-1) < size <= 1<<e
+	if e > histMaxExp {
+		return histBuckets - 1
+	}
+	step := 1 << (e - 4)
+	sub := (size - 1<<(e-1) + step - 1) / step
+	return 1 + (e-1-histMinExp)*histSub + sub - 1
+}
+
+// histSize returns the upper bound of bucket i.
+func histSize(i int) int {
+	if i == 0 {
+		return 1 << histMinExp
+	}
+	i--
+	e := i/histSub + histMinExp + 1
+	sub := i%histSub + 1
+	return 1<<(e-1) + sub<<(e-4)
+}
+
+// HistogramBucket counts the requested sizes above the previous bucket and
+// up to Size bytes.
+type HistogramBucket struct {
+	Size  int
+	Count uint64
+}
+
+// Histogram is a count of requested sizes, holding the non-empty buckets in
+// ascending order.
+type Histogram []HistogramBucket
+
+// Waste returns the fraction of the bytes handed out that classes would
+// waste for the requests of h. Sizes beyond the largest class are not pooled:
+// each of them costs a fresh allocation, so their bytes count as wasted in
+// full.
+func (h Histogram) Waste(classes []int) float64 {
+	var used, wasted float64
+	for _, b := range h {
+		used += float64(b.Count) * float64(b.Size)
+		i := sort.SearchInts(classes, b.Size)
+		if i < len(classes) {
+			wasted += float64(b.Count) * float64(classes[i]-b.Size)
+		} else {
+			wasted += float64(b.Count) * float64(b.Size)
+		}
+	}
+	if used+wasted == 0 {
+		return 0
+	}
+	return wasted / (used + wasted)
+}
+
+// ProposeClasses returns a ladder of at most n classes minimizing the bytes
+// wasted for the requests of h. Every class is a bucket bound of h, the
+// largest one holding the largest request.
+func ProposeClasses(h Histogram, n int) []int {
+	if len(h) == 0 || n <= 0 {
+		return nil
+	}
+	if len(h) <= n {
+		res := make([]int, len(h))
+		for i, b := range h {
+			res[i] = b.Size
+		}
+		return res
+	}
+
+	// cnt and sum are prefix sums of the counts and the requested bytes.
+	cnt := make([]float64, len(h)+1)
+	sum := make([]float64, len(h)+1)
+	for i, b := range h {
+		cnt[i+1] = cnt[i] + float64(b.Count)
+		sum[i+1] = sum[i] + float64(b.Count)*float64(b.Size)
+	}
+	// cost is the waste of serving buckets i to j with a class of h[j].Size.
+	cost := func(i, j int) float64 {
+		return float64(h[j].Size)*(cnt[j+1]-cnt[i]) - (sum[j+1] - sum[i])
+	}
+
+	// best[k][j] is the least waste serving buckets 0 to j with at most k+1
+	// classes, the largest one at j, and from[k][j] the previous class.
+	best := make([][]float64, n)
+	from := make([][]int, n)
+	for k := range best {
+		best[k] = make([]float64, len(h))
+		from[k] = make([]int, len(h))
+	}
+	for j := range h {
+		best[0][j] = cost(0, j)
+		from[0][j] = -1
+	}
+	for k := 1; k < n; k++ {
+		for j := range h {
+			best[k][j], from[k][j] = best[k-1][j], from[k-1][j]
+			for i := k - 1; i < j; i++ {
+				if w := best[k-1][i] + cost(i+1, j); w < best[k][j] {
+					best[k][j], from[k][j] = w, i
+				}
+			}
+		}
+	}
+
+	var res []int
+	for k, j := n-1, len(h)-1; j >= 0; k-- {
+		res = append(res, h[j].Size)
+		j = from[k][j]
+	}
+	sort.Ints(res)
+	return res
+}
+
+// Adaptive configures the size-class tuning of a Pool, see WithAdaptive.
+type Adaptive struct {
+	// Window is the number of gets after which a ladder is proposed and the
+	// histogram starts over. Zero only records the histogram.
+	Window int
+
+	// Classes is the number of classes of a proposed ladder. Zero keeps the
+	// number of classes of the current ladder. A proposed ladder keeps the
+	// largest class of the current one on top of those.
+	Classes int
+
+	// Auto switches the pool to a proposed ladder when it wastes less than
+	// the current one over the window.
+	Auto bool
+
+	// OnProposal, if not nil, is called with every proposed ladder.
+	OnProposal func(classes []int)
+}
+
+// WithAdaptive records a histogram of the requested sizes, see
+// Pool.Histogram, and tunes the class ladder from it as configured by a.
+func WithAdaptive(a Adaptive) Option {
+	return func(p *Pool) {
+		p.hist = &histogram{cfg: a}
+	}
+}
+
+type histogram struct {
+	cfg     Adaptive
+	gets    atomic.Uint64
+	busy    atomic.Bool
+	buckets [histBuckets]atomic.Uint64
+}
+
+// record counts a request of size bytes and starts a proposal at the end of
+// a window.
+func (p *Pool) record(size int) {
+	h := p.hist
+	h.buckets[histIndex(size)].Add(1)
+	if w := uint64(h.cfg.Window); w > 0 && h.gets.Add(1)%w == 0 && h.busy.CompareAndSwap(false, true) {
+		go p.adapt()
+	}
+}
+
+// adapt proposes a ladder from the last window and switches to it if
+// configured so.
+func (p *Pool) adapt() {
+	defer p.hist.busy.Store(false)
+	h := p.Histogram()
+	p.ResetHistogram()
+
+	cur := p.Classes()
+	n := p.hist.cfg.Classes
+	if n <= 0 {
+		n = len(cur)
+	}
+	classes := ProposeClasses(h, n)
+	if len(classes) == 0 {
+		return
+	}
+	// Keep pooling the sizes the window did not see up to the current top.
+	if top := cur[len(cur)-1]; classes[len(classes)-1] < top {
+		classes = append(classes, top)
+	}
+	if p.hist.cfg.OnProposal != nil {
+		p.hist.cfg.OnProposal(classes)
+	}
+	if p.hist.cfg.Auto && h.Waste(classes) < h.Waste(cur) {
+		p.Retune(classes)
+	}
+}
+
+// Histogram returns the requested sizes recorded since the start of the
+// current window. It is empty unless p was created WithAdaptive.
+func (p *Pool) Histogram() Histogram {
+	if p.hist == nil {
+		return nil
+	}
+	var res Histogram
+	for i := range p.hist.buckets {
+		if n := p.hist.buckets[i].Load(); n > 0 {
+			res = append(res, HistogramBucket{Size: histSize(i), Count: n})
+		}
+	}
+	return res
+}
+
+// ResetHistogram clears the recorded sizes.
+func (p *Pool) ResetHistogram() {
+	if p.hist == nil {
+		return
+	}
+	for i := range p.hist.buckets {
+		p.hist.buckets[i].Store(0)
+	}
+}
+
+// Retune switches p to a new class ladder, which must be positive and
+// strictly increasing. Buffers of the previous ladders given back later,
+// oversized ones included, are dropped, even in strict mode. The counters of the classes start over.
+func (p *Pool) Retune(classes []int) error {
+	if !validClasses(classes) {
+		return ErrInvalidClasses
+	}
+	p.tuneMu.Lock()
+	defer p.tuneMu.Unlock()
+
+	old := p.tab.Load()
+	retired := append(append([]int(nil), old.retired...), old.sizes...)
+	sort.Ints(retired)
+	uniq := retired[:0]
+	for i, size := range retired {
+		if i == 0 || size != retired[i-1] {
+			uniq = append(uniq, size)
+		}
+	}
+	t := p.newTable(classes, uniq)
+	t.retiredMax = old.maxSize()
+	if old.retiredMax > 0 && old.retiredMax < t.retiredMax {
+		t.retiredMax = old.retiredMax
+	}
+	p.tab.Store(t)
+
+	// The buffers kept by the old ladder go away with it.
+	if p.budget > 0 {
+		for i := range old.classes {
+			c := &old.classes[i]
+			p.release(int64(c.size) * (c.free.Swap(0) + c.victim.Swap(0)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistIndex(t *testing.T) {
+	for size := 1; size < 1<<20; size++ {
+		i := histIndex(size)
+		if histSize(i) < size || (i > 0 && histSize(i-1) >= size) {
+			t.Fatalf("size %d in bucket %d of bound %d", size, i, histSize(i))
+		}
+	}
+	for i := 1; i < histBuckets; i++ {
+		if histSize(i) <= histSize(i-1) {
+			t.Fatalf("expect: increasing bounds; got: bucket %d of bound %d after %d", i, histSize(i), histSize(i-1))
+		}
+	}
+	if i := histIndex(math.MaxInt); i < 0 || i >= histBuckets || histSize(i) <= 0 {
+		t.Fatalf("expect: a bucket of positive bound; got: bucket %d of bound %d", i, histSize(i))
+	}
+}
+
+func TestProposeClasses(t *testing.T) {
+	h := Histogram{{100, 10}, {200, 1}, {5120, 50}, {9000, 1}}
+	cases := map[string]struct {
+		N   int
+		Exp []int
+	}{
+		"all":  {4, []int{100, 200, 5120, 9000}},
+		"more": {8, []int{100, 200, 5120, 9000}},
+		"two":  {2, []int{5120, 9000}},
+		"tri":  {3, []int{200, 5120, 9000}},
+	}
+	for name, cas := range cases {
+		got := ProposeClasses(h, cas.N)
+		if len(got) != len(cas.Exp) {
+			t.Fatalf("case: %s  expect: %v; got: %v", name, cas.Exp, got)
+		}
+		for i := range got {
+			if got[i] != cas.Exp[i] {
+				t.Fatalf("case: %s  expect: %v; got: %v", name, cas.Exp, got)
+			}
+		}
+	}
+	if w := h.Waste([]int{100, 200, 5120, 9000}); w != 0 {
+		t.Fatalf("expect: no waste; got: %v", w)
+	}
+	// Unpooled sizes are wasted in full.
+	if w, exp := h.Waste([]int{100, 200, 5120}), 9000.0/(266200+9000); math.Abs(w-exp) > 1e-9 {
+		t.Fatalf("expect: waste=%v without 9000; got: %v", exp, w)
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	proposals := make(chan []int, 1)
+	p := MustNew(class_to_size[:], WithStrict(), WithAdaptive(Adaptive{
+		Window:     100,
+		Classes:    4,
+		Auto:       true,
+		OnProposal: func(classes []int) { proposals <- classes },
+	}))
+
+	old := p.Get(5000, false)
+	for i := 0; i < 99; i++ {
+		p.Put(p.Get(5000, false))
+	}
+	select {
+	case classes := <-proposals:
+		if len(classes) != 2 || classes[0] != 5120 || classes[1] != 327680 {
+			t.Fatalf("expect: [5120 327680]; got: %v", classes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expect: a proposal after the window")
+	}
+	for i := 0; i < 100 && len(p.Classes()) != 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if classes := p.Classes(); len(classes) != 2 || classes[0] != 5120 || classes[1] != 327680 {
+		t.Fatalf("expect: switched to [5120 327680]; got: %v", classes)
+	}
+	if len(p.Histogram()) != 0 {
+		t.Fatalf("expect: histogram reset; got: %v", p.Histogram())
+	}
+
+	// A buffer of the retired ladder is dropped even in strict mode.
+	p.Put(old)
+	if buf := p.Get(5000, false); cap(buf) != 5120 {
+		t.Fatalf("expect: cap=5120; got: cap=%d", cap(buf))
+	}
+	if buf := p.Get(200000, false); cap(buf) != 327680 {
+		t.Fatalf("expect: the top class kept; got: cap=%d", cap(buf))
+	}
+	if err := p.Retune(nil); !errors.Is(err, ErrInvalidClasses) {
+		t.Fatalf("expect: %v; got: %v", ErrInvalidClasses, err)
+	}
+}
+
+func TestRetuneLarge(t *testing.T) {
+	p := MustNew([]int{1024}, WithStrict(), WithStats())
+	big := p.Get(8192, false)
+	if err := p.Retune([]int{4096, 16384}); err != nil {
+		t.Fatalf("Retune: %v", err)
+	}
+	big2 := p.Get(32768, false)
+	if err := p.Retune([]int{65536}); err != nil {
+		t.Fatalf("Retune: %v", err)
+	}
+	// Oversized buffers of both previous ladders are dropped, not foreign.
+	p.Put(big)
+	p.Put(big2)
+	if st := p.LargeStats(); st.Puts != 2 || st.InUseBytes != 0 {
+		t.Fatalf("expect: puts=2, nothing in use; got: %+v", st)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect: panic on a foreign buffer")
+		}
+	}()
+	p.Put(make([]byte, 0, 2048))
+}
@@ -0,0 +1,10 @@
+package pool
+
+// WithArena serves the requests beyond the largest class from a, outside the
+// Go heap, instead of following the LargePolicy of p. The arena is shared,
+// not owned: closing it is up to the caller.
+func WithArena(a *Arena) Option {
+	return func(p *Pool) {
+		p.arena = a
+	}
+}
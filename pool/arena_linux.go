@@ -0,0 +1,122 @@
+package pool
+
+import (
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// Arena hands out big buffers mapped with mmap, outside the Go heap, so they
+// do not count towards GC pacing. Buffers given back are kept mapped for
+// reuse after their pages are released with MADV_DONTNEED, up to a number of
+// idle bytes; the others are unmapped. An Arena is safe for concurrent use.
+//
+// Built with the pooldebug tag, a released buffer is made inaccessible and
+// its address range is never reused, so any use after Put faults.
+type Arena struct {
+	mu          sync.Mutex
+	maxIdle     int
+	idleBytes   int
+	idle        map[int][][]byte
+	outstanding map[*byte][]byte
+	closed      bool
+}
+
+// mmap maps the arena buffers, replaced by tests to make it fail.
+var mmap = unix.Mmap
+
+// NewArena creates an Arena keeping at most maxIdle bytes mapped for reuse.
+func NewArena(maxIdle int) (*Arena, error) {
+	return &Arena{
+		maxIdle:     maxIdle,
+		idle:        map[int][][]byte{},
+		outstanding: map[*byte][]byte{},
+	}, nil
+}
+
+func pageSize(size int) int {
+	page := unix.Getpagesize()
+	return (size + page - 1) / page * page
+}
+
+// Get returns a buffer of size bytes, or zero length if zero is true, with a
+// capacity rounded up to the page size. Its content is always zeroed. If the
+// mapping fails the buffer is allocated on the Go heap instead.
+func (a *Arena) Get(size int, zero bool) []byte {
+	n := pageSize(size)
+	a.mu.Lock()
+	var mem []byte
+	if bufs := a.idle[n]; len(bufs) > 0 {
+		mem = bufs[len(bufs)-1]
+		a.idle[n] = bufs[:len(bufs)-1]
+		a.idleBytes -= n
+	}
+	a.mu.Unlock()
+
+	if mem == nil {
+		var err error
+		mem, err = mmap(-1, 0, n, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+		if err != nil {
+			if zero {
+				return make([]byte, 0, n)
+			}
+			return make([]byte, size, n)
+		}
+	}
+	a.mu.Lock()
+	a.outstanding[&mem[0]] = mem
+	a.mu.Unlock()
+	if zero {
+		return mem[:0]
+	}
+	return mem[:size]
+}
+
+// Put gives buf back to the arena. Buffers not obtained from the arena are
+// ignored.
+func (a *Arena) Put(buf []byte) {
+	if cap(buf) == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := &buf[:1][0]
+	mem, ok := a.outstanding[key]
+	if !ok {
+		return
+	}
+	delete(a.outstanding, key)
+
+	// The pages are given back to the kernel either way; a reused mapping
+	// reads as zeros.
+	unix.Madvise(mem, unix.MADV_DONTNEED)
+	if debugBuild {
+		unix.Mprotect(mem, unix.PROT_NONE)
+		return
+	}
+	if a.closed || a.idleBytes+len(mem) > a.maxIdle {
+		unix.Munmap(mem)
+		return
+	}
+	a.idle[len(mem)] = append(a.idle[len(mem)], mem)
+	a.idleBytes += len(mem)
+}
+
+// Close unmaps the idle buffers. Buffers still handed out are unmapped when
+// they are given back.
+func (a *Arena) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	var err error
+	for n, bufs := range a.idle {
+		for _, mem := range bufs {
+			if e := unix.Munmap(mem); e != nil && err == nil {
+				err = e
+			}
+		}
+		delete(a.idle, n)
+	}
+	a.idleBytes = 0
+	return err
+}
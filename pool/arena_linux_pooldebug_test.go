@@ -0,0 +1,24 @@
+//go:build pooldebug
+// +build pooldebug
+
+package pool
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestArenaUseAfterPut(t *testing.T) {
+	a, _ := NewArena(1 << 20)
+	defer a.Close()
+	buf := a.Get(4096, false)
+	a.Put(buf)
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect: fault on use after Put")
+		}
+	}()
+	buf[0] = 1
+}
@@ -0,0 +1,63 @@
+package pool
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestArena(t *testing.T) {
+	a, err := NewArena(1 << 20)
+	if err != nil {
+		t.Fatalf("NewArena: %v", err)
+	}
+	defer a.Close()
+
+	p := MustNew([]int{1024}, WithArena(a), WithStats())
+	buf := p.Get(300000, false)
+	if len(buf) != 300000 || cap(buf)%LargeAlign != 0 {
+		t.Fatalf("expect: len=300000, page aligned cap; got: len=%d, cap=%d", len(buf), cap(buf))
+	}
+	first := &buf[0]
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	p.Put(buf)
+
+	buf = p.Get(300000, true)
+	if !debugBuild && &buf[:1][0] != first {
+		t.Fatalf("expect: idle mapping reused")
+	}
+	buf = buf[:300000]
+	for i, c := range buf {
+		if c != 0 {
+			t.Fatalf("expect: zeroed pages; got: %#x at %d", c, i)
+		}
+	}
+	p.Put(buf)
+
+	// Foreign buffers are ignored.
+	a.Put(make([]byte, 4096))
+	if st := p.LargeStats(); st.Gets != 2 || st.Puts != 2 {
+		t.Fatalf("expect: gets=2, puts=2; got: %+v", st)
+	}
+}
+
+func TestArenaMmapFailure(t *testing.T) {
+	defer func(f func(int, int64, int, int, int) ([]byte, error)) { mmap = f }(mmap)
+	mmap = func(int, int64, int, int, int) ([]byte, error) {
+		return nil, unix.ENOMEM
+	}
+	a, _ := NewArena(1 << 20)
+	defer a.Close()
+
+	n := pageSize(5000)
+	if buf := a.Get(5000, true); len(buf) != 0 || cap(buf) != n {
+		t.Fatalf("expect: len=0, cap=%d; got: len=%d, cap=%d", n, len(buf), cap(buf))
+	}
+	buf := a.Get(5000, false)
+	if len(buf) != 5000 || cap(buf) != n {
+		t.Fatalf("expect: len=5000, cap=%d; got: len=%d, cap=%d", n, len(buf), cap(buf))
+	}
+	a.Put(buf)
+}
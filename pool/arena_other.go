@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+package pool
+
+import "errors"
+
+// Arena hands out big buffers mapped outside the Go heap. It is only
+// available on Linux.
+type Arena struct{}
+
+// NewArena always fails with errors.ErrUnsupported outside Linux.
+func NewArena(maxIdle int) (*Arena, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// Get allocates a buffer of size bytes on the Go heap.
+func (a *Arena) Get(size int, zero bool) []byte {
+	if zero {
+		return make([]byte, 0, size)
+	}
+	return make([]byte, size)
+}
+
+// Put does nothing.
+func (a *Arena) Put(buf []byte) {}
+
+// Close does nothing.
+func (a *Arena) Close() error { return nil }
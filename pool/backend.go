@@ -0,0 +1,145 @@
+package pool
+
+import (
+	"errors"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// backend stores the free buffers of one size class.
+type backend interface {
+	// get returns a free buffer of the class size, or nil if there is none.
+	get() []byte
+
+	// put stores a buffer of the class size, or drops it and returns false.
+	put(buf []byte) bool
+}
+
+// syncBackend keeps free buffers in a sync.Pool, which is drained by the
+// garbage collector.
+type syncBackend struct {
+	pool sync.Pool
+}
+
+func (b *syncBackend) get() []byte {
+	buf, _ := b.pool.Get().([]byte)
+	return buf
+}
+
+func (b *syncBackend) put(buf []byte) bool {
+	b.pool.Put(buf)
+	return true
+}
+
+// ErrInvalidSlab is returned by New when WithSlab is given a high water
+// mark that is not positive.
+var ErrInvalidSlab = errors.New("pool: slab high water must be positive")
+
+// WithSlab serves the classes up to maxSize bytes from a slab instead of a
+// sync.Pool. A slab keeps its free buffers across garbage collections in
+// lock-free shards, one per P, each retaining at most highWater buffers;
+// buffers beyond that are dropped. highWater must be positive.
+func WithSlab(maxSize, highWater int) Option {
+	return func(p *Pool) {
+		p.slabMax = maxSize
+		p.slabHighWater = highWater
+	}
+}
+
+// slab is a set of fixed-size free lists. Each slot holds the address of the
+// first byte of a free buffer, which is enough to rebuild the slice since
+// every buffer has the class size.
+type slab struct {
+	size   int
+	shards []slabShard
+}
+
+// slabShard is one free list. A buffer is counted in avail once stored, and
+// a slot in used before it is filled, so that get skips an empty shard and
+// put a full one without scanning their slots. A caller that reserved a
+// buffer or a slot is bound to find it.
+type slabShard struct {
+	slots []atomic.Pointer[byte]
+	avail atomic.Int64
+	used  atomic.Int64
+}
+
+func newSlab(size, highWater int) *slab {
+	s := &slab{
+		size:   size,
+		shards: make([]slabShard, runtime.GOMAXPROCS(0)),
+	}
+	for i := range s.shards {
+		s.shards[i].slots = make([]atomic.Pointer[byte], highWater)
+	}
+	return s
+}
+
+// shard picks the shard to start from. The runtime random source is per M,
+// so concurrent callers spread over the shards without sharing state.
+func (s *slab) shard() int {
+	return int(rand.Uint32N(uint32(len(s.shards))))
+}
+
+// get takes from the preferred shard, then steals from the others. Every
+// shard is looked at before giving up, so a nil result means the slab held
+// no buffer, and a miss does not add one more buffer to retain.
+func (s *slab) get() []byte {
+	i := s.shard()
+	for n := range s.shards {
+		sh := &s.shards[(i+n)%len(s.shards)]
+		if !decrement(&sh.avail) {
+			continue
+		}
+		for {
+			for j := range sh.slots {
+				if sh.slots[j].Load() == nil {
+					continue
+				}
+				if ptr := sh.slots[j].Swap(nil); ptr != nil {
+					sh.used.Add(-1)
+					return unsafe.Slice(ptr, s.size)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// put stores buf into the preferred shard, or the first other one with room.
+func (s *slab) put(buf []byte) bool {
+	ptr := unsafe.SliceData(buf)
+	i := s.shard()
+	for n := range s.shards {
+		sh := &s.shards[(i+n)%len(s.shards)]
+		if !incrementBelow(&sh.used, int64(len(sh.slots))) {
+			continue
+		}
+		for {
+			for j := range sh.slots {
+				if sh.slots[j].Load() == nil && sh.slots[j].CompareAndSwap(nil, ptr) {
+					sh.avail.Add(1)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// incrementBelow adds one to n unless it reached max, and reports whether it
+// did.
+func incrementBelow(n *atomic.Int64, max int64) bool {
+	for {
+		cur := n.Load()
+		if cur >= max {
+			return false
+		}
+		if n.CompareAndSwap(cur, cur+1) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestSlab(t *testing.T) {
+	p := MustNew([]int{64, 128, 4096}, WithSlab(128, 4), WithStats())
+	if _, ok := p.tab.Load().classes[1].backend.(*slab); !ok {
+		t.Fatalf("expect: slab backend for class 128")
+	}
+	if _, ok := p.tab.Load().classes[2].backend.(*syncBackend); !ok {
+		t.Fatalf("expect: sync.Pool backend for class 4096")
+	}
+
+	bufs := make([][]byte, 3)
+	for i := range bufs {
+		bufs[i] = p.Get(100, false)
+		bufs[i][0] = byte(i)
+	}
+	for _, buf := range bufs {
+		p.Put(buf)
+	}
+	runtime.GC()
+	runtime.GC()
+
+	p.ResetStats()
+	for range bufs {
+		buf := p.Get(128, true)
+		if cap(buf) != 128 {
+			t.Fatalf("expect: cap=128; got: cap=%d", cap(buf))
+		}
+		defer p.Put(buf)
+	}
+	if st := p.Stats()[1]; st.Misses != 0 {
+		t.Fatalf("expect: buffers survived GC; got: %+v", st)
+	}
+}
+
+// held returns the number of buffers kept by s.
+func (s *slab) held() int {
+	var n int
+	for i := range s.shards {
+		sh := &s.shards[i]
+		for j := range sh.slots {
+			if sh.slots[j].Load() != nil {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestSlabManyShards(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(16))
+	p := MustNew([]int{64, 128}, WithSlab(128, 4), WithStats())
+	for range 200 {
+		p.Put(p.Get(64, false))
+	}
+	if st := p.Stats()[0]; st.Misses != 1 {
+		t.Fatalf("expect: only the first get missing; got: %+v", st)
+	}
+	if n := p.tab.Load().classes[0].backend.(*slab).held(); n != 1 {
+		t.Fatalf("expect: one buffer retained; got: %d", n)
+	}
+}
+
+func TestSlabHighWater(t *testing.T) {
+	s := newSlab(32, 2)
+	for i := 0; i < 8*len(s.shards); i++ {
+		s.put(make([]byte, 32))
+	}
+	if n := s.held(); n != 2*len(s.shards) {
+		t.Fatalf("expect: %d retained; got: %d", 2*len(s.shards), n)
+	}
+	for i := 0; i < 2*len(s.shards); i++ {
+		if s.get() == nil {
+			t.Fatalf("expect: %d buffers; got: %d", 2*len(s.shards), i)
+		}
+	}
+	if s.get() != nil || s.held() != 0 {
+		t.Fatalf("expect: empty slab; got: %d retained", s.held())
+	}
+
+	for _, hw := range []int{0, -1} {
+		if _, err := New([]int{64}, WithSlab(64, hw)); err != ErrInvalidSlab {
+			t.Fatalf("case: highWater=%d  expect: %v; got: %v", hw, ErrInvalidSlab, err)
+		}
+	}
+}
+
+func benchmarkGetPut(b *testing.B, p *Pool, gcEvery int) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var n int
+		for pb.Next() {
+			buf := p.Get(256, false)
+			buf[0] = 1
+			p.Put(buf)
+			if n++; gcEvery > 0 && n%gcEvery == 0 {
+				runtime.GC()
+			}
+		}
+	})
+}
+
+func BenchmarkGetPut(b *testing.B) {
+	b.Run("sync", func(b *testing.B) {
+		benchmarkGetPut(b, MustNew(class_to_size[:]), 0)
+	})
+	b.Run("slab", func(b *testing.B) {
+		benchmarkGetPut(b, MustNew(class_to_size[:], WithSlab(512, 64)), 0)
+	})
+}
+
+func BenchmarkGetPutGC(b *testing.B) {
+	b.Run("sync", func(b *testing.B) {
+		benchmarkGetPut(b, MustNew(class_to_size[:]), 1000)
+	})
+	b.Run("slab", func(b *testing.B) {
+		benchmarkGetPut(b, MustNew(class_to_size[:], WithSlab(512, 64)), 1000)
+	})
+}
@@ -0,0 +1,188 @@
+package pool
+
+import (
+	"runtime"
+	"sync/atomic"
+	"weak"
+)
+
+// WithBudget caps the bytes retained by p across all classes, including the
+// oversized buffers kept with LargeCache. When a buffer given back would
+// exceed the budget, buffers of larger classes are shed first, starting
+// with the oversized ones; if there is none the returned buffer is dropped.
+// onDrop, if not nil, is called with the capacity of every buffer dropped
+// because of the budget; it must not call back into p.
+//
+// Buffers kept by a sync.Pool are freed by the garbage collector without
+// notice, so for those classes the retained bytes are an estimate assuming
+// a buffer survives at most two collections.
+func WithBudget(maxBytes int64, onDrop func(size int)) Option {
+	return func(p *Pool) {
+		p.budget = maxBytes
+		p.onDrop = onDrop
+	}
+}
+
+// Retained returns the bytes currently retained by p. It is only tracked
+// when p has a budget.
+func (p *Pool) Retained() int64 {
+	return p.retained.Load()
+}
+
+// reserve accounts size more retained bytes for a buffer of class in t,
+// shedding larger classes to make room. It reports false if the buffer must
+// be dropped. A class of -1 stands for an oversized buffer.
+func (p *Pool) reserve(t *table, size, class int) bool {
+	for {
+		cur := p.retained.Load()
+		if cur+int64(size) <= p.budget {
+			if p.retained.CompareAndSwap(cur, cur+int64(size)) {
+				return true
+			}
+			continue
+		}
+		if !p.shed(t, class) {
+			return false
+		}
+	}
+}
+
+// shed drops one buffer larger than those of class, the oversized ones
+// first, and reports whether it found one.
+func (p *Pool) shed(t *table, class int) bool {
+	if class < 0 {
+		return false
+	}
+	if n := p.large.evict(); n > 0 {
+		p.release(int64(n))
+		if p.statsOn.Load() {
+			p.large.drops.Add(1)
+		}
+		p.dropped(n)
+		return true
+	}
+	for i := len(t.classes) - 1; i > class; i-- {
+		c := &t.classes[i]
+		if c.free.Load() <= 0 && c.victim.Load() <= 0 {
+			continue
+		}
+		if c.backend.get() == nil {
+			if _, ok := c.backend.(*syncBackend); ok {
+				// The collector got there first.
+				p.release(int64(c.size) * (c.free.Swap(0) + c.victim.Swap(0)))
+			}
+			continue
+		}
+		p.taken(c)
+		if p.statsOn.Load() {
+			c.drops.Add(1)
+		}
+		p.dropped(c.size)
+		return true
+	}
+	return false
+}
+
+// taken accounts a buffer of c leaving the backend.
+func (p *Pool) taken(c *sizeClass) {
+	if decrement(&c.free) || decrement(&c.victim) {
+		p.release(int64(c.size))
+	}
+}
+
+// release accounts n fewer retained bytes.
+func (p *Pool) release(n int64) {
+	for {
+		cur := p.retained.Load()
+		next := cur - n
+		if next < 0 {
+			next = 0
+		}
+		if p.retained.CompareAndSwap(cur, next) {
+			return
+		}
+	}
+}
+
+// dropped reports a buffer of size bytes dropped because of the budget.
+func (p *Pool) dropped(size int) {
+	if p.onDrop != nil {
+		p.onDrop(size)
+	}
+}
+
+// putLarge gives an oversized buffer to the large cache within the budget.
+func (p *Pool) putLarge(buf []byte) {
+	stats := p.statsOn.Load()
+	if stats {
+		p.large.puts.Add(1)
+		p.large.inUse.Add(-int64(cap(buf)))
+	}
+	if p.budget > 0 && p.large.fits(cap(buf)) {
+		if !p.reserve(p.tab.Load(), cap(buf), -1) {
+			if stats {
+				p.large.drops.Add(1)
+			}
+			p.dropped(cap(buf))
+			return
+		}
+		if !p.large.put(buf) {
+			p.release(int64(cap(buf)))
+			if stats {
+				p.large.drops.Add(1)
+			}
+		}
+		return
+	}
+	if !p.large.put(buf) && stats {
+		p.large.drops.Add(1)
+	}
+}
+
+// gcSentinel is allocated unreachable so that its finalizer runs once per
+// garbage collection. It holds a pointer to stay out of the tiny allocator.
+type gcSentinel struct {
+	_ *byte
+}
+
+// watchGC ages the estimates of the sync.Pool classes on every collection,
+// mirroring the victim cache of sync.Pool. It stops once p is unreachable.
+// The estimates are kept when p has a budget or counts statistics.
+func (p *Pool) watchGC() {
+	wp := weak.Make(p)
+	var onGC func(*gcSentinel)
+	onGC = func(*gcSentinel) {
+		p := wp.Value()
+		if p == nil {
+			return
+		}
+		p.aged()
+		runtime.SetFinalizer(&gcSentinel{}, onGC)
+	}
+	runtime.SetFinalizer(&gcSentinel{}, onGC)
+}
+
+func (p *Pool) aged() {
+	t := p.tab.Load()
+	for i := range t.classes {
+		c := &t.classes[i]
+		if _, ok := c.backend.(*syncBackend); !ok {
+			continue
+		}
+		p.release(int64(c.size) * c.victim.Swap(c.free.Swap(0)))
+	}
+}
+
+// decrement subtracts one from n unless it is not positive, and reports
+// whether it did.
+func decrement(n *atomic.Int64) bool {
+	for {
+		cur := n.Load()
+		if cur <= 0 {
+			return false
+		}
+		if n.CompareAndSwap(cur, cur-1) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pool
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	var drops []int
+	p := MustNew([]int{64, 128, 256}, WithSlab(256, 8), WithBudget(512, func(size int) {
+		drops = append(drops, size)
+	}), WithStats())
+
+	a, b, c := p.Get(256, false), p.Get(128, false), p.Get(128, false)
+	p.Put(a)
+	p.Put(b)
+	p.Put(c)
+	if p.Retained() != 512 || len(drops) != 0 {
+		t.Fatalf("expect: retained=512, no drops; got: retained=%d, drops=%v", p.Retained(), drops)
+	}
+
+	// A 64 bytes buffer sheds the largest class first.
+	p.Put(p.Get(64, false)[:0:64])
+	if p.Retained() != 320 || len(drops) != 1 || drops[0] != 256 {
+		t.Fatalf("expect: retained=320, drops=[256]; got: retained=%d, drops=%v", p.Retained(), drops)
+	}
+	if st := p.Stats()[2]; st.Drops != 1 {
+		t.Fatalf("expect: one drop in class 256; got: %+v", st)
+	}
+
+	// Nothing larger than 256 bytes to shed: the buffer itself is dropped.
+	d, e := p.Get(256, false), p.Get(256, false)
+	p.Put(d)
+	p.Put(e)
+	if p.Retained() != 320 || len(drops) != 3 {
+		t.Fatalf("expect: retained=320, 3 drops; got: retained=%d, drops=%v", p.Retained(), drops)
+	}
+
+	p.Get(128, false)
+	if p.Retained() != 192 {
+		t.Fatalf("expect: retained=192 after a hit; got: retained=%d", p.Retained())
+	}
+}
+
+func TestBudgetSlabShards(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(16))
+	p := MustNew([]int{64, 128}, WithSlab(128, 64), WithBudget(1024, nil))
+	held := func() int64 {
+		t := p.tab.Load()
+		var n int64
+		for i := range t.classes {
+			n += int64(t.classes[i].size * t.classes[i].backend.(*slab).held())
+		}
+		return n
+	}
+
+	var bufs [][]byte
+	for range 8 {
+		bufs = append(bufs, p.Get(128, false))
+	}
+	for range 16 {
+		bufs = append(bufs, p.Get(64, false))
+	}
+	for _, buf := range bufs {
+		p.Put(buf)
+	}
+	// The 64 bytes buffers shed the 128 bytes ones wherever they sit.
+	if p.Retained() != 1024 || held() != 1024 {
+		t.Fatalf("expect: retained=1024, held=1024; got: retained=%d, held=%d", p.Retained(), held())
+	}
+}
+
+func TestBudgetGC(t *testing.T) {
+	p := MustNew([]int{64}, WithBudget(1024, nil))
+	p.Put(p.Get(64, false))
+	if p.Retained() != 64 {
+		t.Fatalf("expect: retained=64; got: retained=%d", p.Retained())
+	}
+	// Finalizers run on their own goroutine after the collection, so give
+	// them a few more cycles than the two needed.
+	for i := 0; i < 100 && p.Retained() != 0; i++ {
+		runtime.GC()
+		runtime.Gosched()
+	}
+	if p.Retained() != 0 {
+		t.Fatalf("expect: retained=0 after two collections; got: retained=%d", p.Retained())
+	}
+}
+
+func TestBudgetLarge(t *testing.T) {
+	p := MustNew([]int{1024}, WithLarge(LargeCache, 1<<20), WithBudget(2*LargeAlign+1024, nil))
+	big, a, b := p.Get(2*LargeAlign, false), p.Get(1024, false), p.Get(1024, false)
+	p.Put(big)
+	p.Put(a)
+	if p.Retained() != 2*LargeAlign+1024 {
+		t.Fatalf("expect: retained=%d; got: retained=%d", 2*LargeAlign+1024, p.Retained())
+	}
+	p.Put(b)
+	if p.Retained() != 2*1024 || p.large.retained != 0 {
+		t.Fatalf("expect: large buffer shed first, retained=2048; got: retained=%d, large=%d", p.Retained(), p.large.retained)
+	}
+}
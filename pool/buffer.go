@@ -0,0 +1,181 @@
+package pool
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// MinRead is the minimum slice size passed to a Read call by
+// Buffer.ReadFrom.
+const MinRead = 512
+
+// Buffer is a growable byte buffer backed by the size classes of a Pool,
+// similar to bytes.Buffer. When it outgrows its buffer it moves to the next
+// class able to hold the data and gives the old buffer back to the pool.
+//
+// Create a Buffer with NewBuffer and call Release once done with it. A
+// released Buffer is empty and may be used again.
+type Buffer struct {
+	p   *Pool
+	buf []byte // contents are buf[off:]
+	off int
+}
+
+// NewBuffer returns an empty Buffer backed by Default with room for size
+// bytes.
+func NewBuffer(size int) *Buffer {
+	return Default.NewBuffer(size)
+}
+
+// NewBuffer returns an empty Buffer backed by p with room for size bytes.
+func (p *Pool) NewBuffer(size int) *Buffer {
+	return &Buffer{p: p, buf: p.Get(size, true)}
+}
+
+// Bytes returns the unread portion of the buffer. The slice is only valid
+// until the next write, Reset or Release.
+func (b *Buffer) Bytes() []byte { return b.buf[b.off:] }
+
+// String returns the unread portion of the buffer as a string.
+func (b *Buffer) String() string { return string(b.buf[b.off:]) }
+
+// Len returns the number of unread bytes.
+func (b *Buffer) Len() int { return len(b.buf) - b.off }
+
+// Cap returns the capacity of the underlying buffer.
+func (b *Buffer) Cap() int { return cap(b.buf) }
+
+// Reset empties the buffer but keeps the underlying storage.
+func (b *Buffer) Reset() {
+	b.buf = b.buf[:0]
+	b.off = 0
+}
+
+// Release gives the underlying storage back to the pool and empties the
+// buffer.
+func (b *Buffer) Release() {
+	if b.buf != nil {
+		b.p.Put(b.buf)
+	}
+	b.buf = nil
+	b.off = 0
+}
+
+// Grow makes room for at least n more bytes without another step.
+func (b *Buffer) Grow(n int) {
+	if n < 0 {
+		panic("pool.Buffer.Grow: negative count")
+	}
+	b.grow(n)
+}
+
+func (b *Buffer) grow(n int) {
+	if len(b.buf)+n <= cap(b.buf) {
+		return
+	}
+	m := b.Len()
+	if b.off > 0 && m+n <= cap(b.buf) {
+		copy(b.buf, b.buf[b.off:])
+		b.buf = b.buf[:m]
+		b.off = 0
+		return
+	}
+	want := m + n
+	if want > b.p.maxSize() && want < 2*cap(b.buf) {
+		// Beyond the classes, double to keep appends amortized.
+		want = 2 * cap(b.buf)
+	}
+	buf := append(b.p.Get(want, true), b.buf[b.off:]...)
+	if b.buf != nil {
+		b.p.Put(b.buf)
+	}
+	b.buf = buf
+	b.off = 0
+}
+
+// Write appends p to the buffer. The error is always nil.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.grow(len(p))
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to the buffer. The error is always nil.
+func (b *Buffer) WriteString(s string) (int, error) {
+	b.grow(len(s))
+	b.buf = append(b.buf, s...)
+	return len(s), nil
+}
+
+// WriteByte appends c to the buffer. The error is always nil.
+func (b *Buffer) WriteByte(c byte) error {
+	b.grow(1)
+	b.buf = append(b.buf, c)
+	return nil
+}
+
+// WriteRune appends the UTF-8 encoding of r to the buffer. The error is
+// always nil.
+func (b *Buffer) WriteRune(r rune) (int, error) {
+	b.grow(utf8.UTFMax)
+	n := len(b.buf)
+	b.buf = utf8.AppendRune(b.buf, r)
+	return len(b.buf) - n, nil
+}
+
+// Read reads up to len(p) unread bytes into p. It returns io.EOF once the
+// buffer is drained and p is not empty.
+func (b *Buffer) Read(p []byte) (int, error) {
+	if b.Len() == 0 {
+		b.Reset()
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, b.buf[b.off:])
+	b.off += n
+	return n, nil
+}
+
+// ReadFrom reads from r until EOF and appends the data to the buffer. Any
+// error other than io.EOF is returned.
+func (b *Buffer) ReadFrom(r io.Reader) (int64, error) {
+	var total int64
+	for {
+		b.grow(MinRead)
+		n, err := r.Read(b.buf[len(b.buf):cap(b.buf)])
+		if n < 0 {
+			panic("pool.Buffer.ReadFrom: reader returned negative count")
+		}
+		b.buf = b.buf[:len(b.buf)+n]
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// WriteTo writes the unread data to w until the buffer is drained or an
+// error occurs.
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	m := b.Len()
+	if m == 0 {
+		return 0, nil
+	}
+	n, err := w.Write(b.buf[b.off:])
+	if n > m {
+		panic("pool.Buffer.WriteTo: invalid Write count")
+	}
+	b.off += n
+	if err == nil && n != m {
+		err = io.ErrShortWrite
+	}
+	if b.off == len(b.buf) {
+		b.Reset()
+	}
+	return int64(n), err
+}
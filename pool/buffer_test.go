@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	p := MustNew([]int{16, 64, 256}, WithStats())
+	b := p.NewBuffer(1)
+	if b.Cap() != 16 {
+		t.Fatalf("expect: cap=16; got: cap=%d", b.Cap())
+	}
+
+	var exp bytes.Buffer
+	for i := 0; i < 20; i++ {
+		b.WriteString("ab")
+		b.WriteByte('c')
+		b.WriteRune('世')
+		exp.WriteString("abc世")
+	}
+	if b.String() != exp.String() || b.Cap() != 256 {
+		t.Fatalf("expect: %q with cap=256; got: %q with cap=%d", exp.String(), b.String(), b.Cap())
+	}
+	for i, st := range p.Stats() {
+		if st.InUse() != 0 && i != 2 {
+			t.Fatalf("expect: outgrown classes returned; got: %+v", st)
+		}
+	}
+
+	var out bytes.Buffer
+	if n, err := b.WriteTo(&out); err != nil || n != int64(exp.Len()) || out.String() != exp.String() || b.Len() != 0 {
+		t.Fatalf("expect: %d bytes written; got: n=%d, err=%v, len=%d", exp.Len(), n, err, b.Len())
+	}
+
+	src := strings.Repeat("x", 1000)
+	if n, err := b.ReadFrom(strings.NewReader(src)); err != nil || n != 1000 || b.String() != src {
+		t.Fatalf("expect: 1000 bytes read; got: n=%d, err=%v", n, err)
+	}
+	if n, err := io.Copy(&out, io.LimitReader(b, 1000)); err != nil || n != 1000 {
+		t.Fatalf("expect: 1000 bytes copied; got: n=%d, err=%v", n, err)
+	}
+
+	b.Release()
+	for _, st := range p.Stats() {
+		if st.InUse() != 0 {
+			t.Fatalf("expect: every buffer returned; got: %+v", st)
+		}
+	}
+	if st := p.LargeStats(); st.InUse() != 0 {
+		t.Fatalf("expect: every large buffer returned; got: %+v", st)
+	}
+}
@@ -0,0 +1,115 @@
+package pool
+
+import (
+	"io"
+	"net"
+)
+
+// Chain is a message made of several pooled chunks, e.g. a header and a
+// body, written to a connection at once with writev through net.Buffers.
+// Call Release once done with it to give every chunk back to the pool.
+type Chain struct {
+	p     *Pool
+	chunk int
+	bufs  net.Buffers
+}
+
+// NewChain returns an empty Chain backed by Default. Write and ReadFrom
+// allocate chunks of chunkSize bytes.
+func NewChain(chunkSize int) *Chain {
+	return Default.NewChain(chunkSize)
+}
+
+// NewChain returns an empty Chain backed by p. Write and ReadFrom allocate
+// chunks of chunkSize bytes.
+func (p *Pool) NewChain(chunkSize int) *Chain {
+	return &Chain{p: p, chunk: chunkSize}
+}
+
+// Alloc appends a chunk of n bytes to the chain and returns it to be filled
+// in place.
+func (c *Chain) Alloc(n int) []byte {
+	buf := c.p.Get(n, false)[:n]
+	c.bufs = append(c.bufs, buf)
+	return buf
+}
+
+// tail returns the spare capacity of the last chunk, allocating a new chunk
+// if there is none.
+func (c *Chain) tail() []byte {
+	if n := len(c.bufs); n > 0 {
+		last := c.bufs[n-1]
+		if len(last) < cap(last) {
+			return last[len(last):cap(last)]
+		}
+	}
+	c.bufs = append(c.bufs, c.p.Get(c.chunk, true))
+	last := c.bufs[len(c.bufs)-1]
+	return last[:cap(last)]
+}
+
+// grow extends the last chunk by n bytes of its spare capacity.
+func (c *Chain) grow(n int) {
+	last := &c.bufs[len(c.bufs)-1]
+	*last = (*last)[:len(*last)+n]
+}
+
+// Write copies p at the end of the chain. The error is always nil.
+func (c *Chain) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		n := copy(c.tail(), p)
+		c.grow(n)
+		p = p[n:]
+	}
+	return total, nil
+}
+
+// ReadFrom reads from r until EOF into fresh chunks appended to the chain.
+// Any error other than io.EOF is returned.
+func (c *Chain) ReadFrom(r io.Reader) (int64, error) {
+	c.bufs = append(c.bufs, c.p.Get(c.chunk, true))
+	var total int64
+	for {
+		n, err := r.Read(c.tail())
+		c.grow(n)
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Buffers returns the chunks of the chain. The slice is a copy, so writing it
+// does not consume the chain, but the chunks are shared with it.
+func (c *Chain) Buffers() net.Buffers {
+	return append(net.Buffers(nil), c.bufs...)
+}
+
+// Len returns the number of bytes in the chain.
+func (c *Chain) Len() int {
+	var n int
+	for _, buf := range c.bufs {
+		n += len(buf)
+	}
+	return n
+}
+
+// WriteTo writes the chain to w, using a single writev when w is a
+// connection supporting it. The chain is left untouched.
+func (c *Chain) WriteTo(w io.Writer) (int64, error) {
+	bufs := c.Buffers()
+	return bufs.WriteTo(w)
+}
+
+// Release gives every chunk back to the pool and empties the chain.
+func (c *Chain) Release() {
+	for i, buf := range c.bufs {
+		c.p.Put(buf)
+		c.bufs[i] = nil
+	}
+	c.bufs = c.bufs[:0]
+}
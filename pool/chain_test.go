@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	p := MustNew([]int{16, 64, 256}, WithStats())
+	body := strings.Repeat("0123456789", 30)
+
+	done := make(chan *Chain)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- nil
+			return
+		}
+		defer conn.Close()
+		c := p.NewChain(64)
+		if _, err := c.ReadFrom(conn); err != nil {
+			t.Errorf("ReadFrom: %v", err)
+		}
+		done <- c
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	out := p.NewChain(64)
+	header := out.Alloc(4)
+	copy(header, "HDR:")
+	out.Write([]byte(body))
+	if out.Len() != 4+len(body) || len(out.Buffers()) != 6 {
+		t.Fatalf("expect: len=%d in 6 chunks; got: len=%d in %d chunks", 4+len(body), out.Len(), len(out.Buffers()))
+	}
+	if n, err := out.WriteTo(conn); err != nil || n != int64(out.Len()) {
+		t.Fatalf("expect: %d bytes written; got: n=%d, err=%v", out.Len(), n, err)
+	}
+	conn.Close()
+
+	in := <-done
+	if in == nil {
+		t.Fatalf("accept failed")
+	}
+	var got bytes.Buffer
+	in.WriteTo(&got)
+	if got.String() != "HDR:"+body {
+		t.Fatalf("expect: %q; got: %q", "HDR:"+body, got.String())
+	}
+
+	out.Release()
+	in.Release()
+	for _, st := range p.Stats() {
+		if st.InUse() != 0 {
+			t.Fatalf("expect: every chunk returned; got: %+v", st)
+		}
+	}
+}
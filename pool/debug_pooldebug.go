@@ -0,0 +1,7 @@
+//go:build pooldebug
+// +build pooldebug
+
+package pool
+
+// debugBuild is true when the package is built with the pooldebug tag.
+const debugBuild = true
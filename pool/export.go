@@ -0,0 +1,117 @@
+package pool
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type metric struct {
+	name, typ, help string
+	value           func(ClassStats) int64
+}
+
+var metrics = []metric{
+	{"pool_gets_total", "counter", "Buffers handed out.", func(s ClassStats) int64 { return int64(s.Gets) }},
+	{"pool_hits_total", "counter", "Buffers handed out from the pool.", func(s ClassStats) int64 { return int64(s.Hits()) }},
+	{"pool_misses_total", "counter", "Buffers allocated because the pool was empty.", func(s ClassStats) int64 { return int64(s.Misses) }},
+	{"pool_puts_total", "counter", "Buffers given back.", func(s ClassStats) int64 { return int64(s.Puts) }},
+	{"pool_drops_total", "counter", "Buffers given back and not kept.", func(s ClassStats) int64 { return int64(s.Drops) }},
+	{"pool_in_use_bytes", "gauge", "Capacity of the buffers handed out and not given back.", func(s ClassStats) int64 { return s.InUseBytes }},
+	{"pool_retained_bytes", "gauge", "Capacity of the buffers kept by the pool.", func(s ClassStats) int64 { return s.RetainedBytes }},
+}
+
+// labelEscaper escapes label values as the exposition format expects, which
+// only knows of backslash, double quote and line feed escapes.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the counters of pools in the Prometheus text
+// exposition format. Every sample is labelled with the name of its pool and
+// the size of its class, "large" standing for the buffers beyond the largest
+// class. Counters are only kept while statistics are enabled.
+func WritePrometheus(w io.Writer, pools map[string]*Pool) error {
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stats := make([][]ClassStats, len(names))
+	for i, name := range names {
+		p := pools[name]
+		stats[i] = append(p.Stats(), p.LargeStats())
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		for i, name := range names {
+			for _, st := range stats[i] {
+				class := "large"
+				if st.Size > 0 {
+					class = strconv.Itoa(st.Size)
+				}
+				fmt.Fprintf(bw, `%s{pool="%s",class="%s"} %d`+"\n", m.name, labelEscaper.Replace(name), class, m.value(st))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// WritePrometheus writes the counters of p, labelled with name, in the
+// Prometheus text exposition format.
+func (p *Pool) WritePrometheus(w io.Writer, name string) error {
+	return WritePrometheus(w, map[string]*Pool{name: p})
+}
+
+// classVar is the expvar form of ClassStats.
+type classVar struct {
+	Size          int    `json:"size"`
+	Gets          uint64 `json:"gets"`
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Puts          uint64 `json:"puts"`
+	Drops         uint64 `json:"drops"`
+	InUseBytes    int64  `json:"in_use_bytes"`
+	RetainedBytes int64  `json:"retained_bytes"`
+}
+
+func newClassVar(s ClassStats) classVar {
+	return classVar{
+		Size:          s.Size,
+		Gets:          s.Gets,
+		Hits:          s.Hits(),
+		Misses:        s.Misses,
+		Puts:          s.Puts,
+		Drops:         s.Drops,
+		InUseBytes:    s.InUseBytes,
+		RetainedBytes: s.RetainedBytes,
+	}
+}
+
+// Var returns an expvar.Var rendering the counters of p as JSON, with one
+// entry per class and one for the buffers beyond the largest class.
+func (p *Pool) Var() expvar.Var {
+	return expvar.Func(func() interface{} {
+		stats := p.Stats()
+		classes := make([]classVar, len(stats))
+		for i, st := range stats {
+			classes[i] = newClassVar(st)
+		}
+		return map[string]interface{}{
+			"classes": classes,
+			"large":   newClassVar(p.LargeStats()),
+		}
+	})
+}
+
+// Publish registers the counters of p under name in expvar, hence in the
+// /debug/vars page. Like expvar.Publish, it panics if name is already
+// registered.
+func (p *Pool) Publish(name string) {
+	expvar.Publish(name, p.Var())
+}
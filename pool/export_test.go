@@ -0,0 +1,72 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	p := MustNew([]int{64, 128}, WithStats())
+	buf := p.Get(100, false)
+	p.Put(p.Get(50, false))
+	p.Get(1000, false)
+
+	var out bytes.Buffer
+	if err := p.WritePrometheus(&out, "rpc"); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	for _, line := range []string{
+		"# TYPE pool_gets_total counter",
+		`pool_gets_total{pool="rpc",class="64"} 1`,
+		`pool_misses_total{pool="rpc",class="128"} 1`,
+		`pool_in_use_bytes{pool="rpc",class="128"} 128`,
+		`pool_retained_bytes{pool="rpc",class="64"} 64`,
+		`pool_in_use_bytes{pool="rpc",class="large"} 4096`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Fatalf("expect: %q in\n%s", line, out.String())
+		}
+	}
+	if n := strings.Count(out.String(), "# TYPE"); n != len(metrics) {
+		t.Fatalf("expect: %d metric families; got: %d", len(metrics), n)
+	}
+	p.Put(buf)
+}
+
+func TestWritePrometheusEscape(t *testing.T) {
+	var out bytes.Buffer
+	if err := WritePrometheus(&out, map[string]*Pool{"a\"b\\c\nd\té": MustNew([]int{64})}); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	line := `pool_gets_total{pool="a\"b\\c\nd` + "\t" + `é",class="64"} 0`
+	if !strings.Contains(out.String(), line+"\n") {
+		t.Fatalf("expect: %q in\n%s", line, out.String())
+	}
+}
+
+func TestPublish(t *testing.T) {
+	p := MustNew([]int{64}, WithStats())
+	p.Put(p.Get(10, false))
+	if expvar.Get("pool_test") == nil {
+		p.Publish("pool_test")
+	}
+
+	var v struct {
+		Classes []struct {
+			Size int    `json:"size"`
+			Gets uint64 `json:"gets"`
+		} `json:"classes"`
+	}
+	if err := json.Unmarshal([]byte(p.Var().String()), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(v.Classes) != 1 || v.Classes[0].Size != 64 || v.Classes[0].Gets != 1 {
+		t.Fatalf("expect: one class of 64 with one get; got: %+v", v)
+	}
+	if expvar.Get("pool_test") == nil {
+		t.Fatalf("expect: pool_test published")
+	}
+}
@@ -0,0 +1,119 @@
+package pool
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// LargeAlign is the granularity of buffers allocated beyond the largest
+// class. Their capacity is the requested size rounded up to it.
+const LargeAlign = 4096
+
+// LargePolicy controls how a Pool treats requests beyond its largest class.
+type LargePolicy int
+
+const (
+	// LargeAlloc allocates oversized buffers directly and never pools them.
+	// This is the default.
+	LargeAlloc LargePolicy = iota
+
+	// LargeCache keeps oversized buffers given back to the pool in a cache
+	// bounded by a number of retained bytes.
+	LargeCache
+)
+
+// WithLarge sets the policy for requests beyond the largest class. With
+// LargeCache at most maxRetained bytes of oversized buffers are kept; the
+// argument is ignored with LargeAlloc.
+func WithLarge(policy LargePolicy, maxRetained int) Option {
+	return func(p *Pool) {
+		p.large.policy = policy
+		p.large.max = maxRetained
+	}
+}
+
+// largeCache holds oversized buffers sorted by capacity.
+type largeCache struct {
+	policy LargePolicy
+	max    int
+
+	mu       sync.Mutex
+	bufs     [][]byte
+	retained int
+
+	classCounters
+	inUse atomic.Int64
+}
+
+func largeSize(size int) int {
+	return (size + LargeAlign - 1) / LargeAlign * LargeAlign
+}
+
+// get returns a buffer of at least size bytes and whether it was cached. A
+// cached buffer is only used if it wastes less than half of its capacity.
+func (l *largeCache) get(size int, stats bool) ([]byte, bool) {
+	if stats {
+		l.gets.Add(1)
+	}
+	if l.policy == LargeCache {
+		l.mu.Lock()
+		i := sort.Search(len(l.bufs), func(i int) bool { return cap(l.bufs[i]) >= size })
+		if i < len(l.bufs) && cap(l.bufs[i]) < 2*size {
+			buf := l.bufs[i]
+			l.bufs = append(l.bufs[:i], l.bufs[i+1:]...)
+			l.retained -= cap(buf)
+			l.mu.Unlock()
+			return buf[:size], true
+		}
+		l.mu.Unlock()
+	}
+	if stats {
+		l.misses.Add(1)
+	}
+	return make([]byte, size, largeSize(size)), false
+}
+
+// fits reports whether the cache would keep a buffer of capacity c.
+func (l *largeCache) fits(c int) bool {
+	if l.policy != LargeCache {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.retained+c <= l.max
+}
+
+// put caches buf if the policy allows it and the cache has room left, and
+// reports whether it did.
+func (l *largeCache) put(buf []byte) bool {
+	if l.policy != LargeCache {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.retained+cap(buf) > l.max {
+		return false
+	}
+	i := sort.Search(len(l.bufs), func(i int) bool { return cap(l.bufs[i]) >= cap(buf) })
+	l.bufs = append(l.bufs, nil)
+	copy(l.bufs[i+1:], l.bufs[i:])
+	l.bufs[i] = buf[:cap(buf)]
+	l.retained += cap(buf)
+	return true
+}
+
+// evict removes the largest cached buffer and returns its capacity, or 0 if
+// the cache is empty.
+func (l *largeCache) evict() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.bufs) == 0 {
+		return 0
+	}
+	buf := l.bufs[len(l.bufs)-1]
+	l.bufs[len(l.bufs)-1] = nil
+	l.bufs = l.bufs[:len(l.bufs)-1]
+	l.retained -= cap(buf)
+	return cap(buf)
+}
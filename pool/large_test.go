@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLarge(t *testing.T) {
+	t.Run("alloc", func(t *testing.T) {
+		buf := GetBuf(1<<20+1, false)
+		if len(buf) != 1<<20+1 || cap(buf) != 1<<20+LargeAlign {
+			t.Fatalf("expect: len=%d, cap=%d; got: len=%d, cap=%d", 1<<20+1, 1<<20+LargeAlign, len(buf), cap(buf))
+		}
+		PutBuf(buf)
+		if buf = GetBuf(1<<20, true); len(buf) != 0 || cap(buf) != 1<<20 {
+			t.Fatalf("expect: len=0, cap=%d; got: len=%d, cap=%d", 1<<20, len(buf), cap(buf))
+		}
+	})
+	t.Run("cache", func(t *testing.T) {
+		p := MustNew([]int{1024}, WithLarge(LargeCache, 3*LargeAlign), WithStats())
+		a := p.Get(2*LargeAlign, false)
+		b := p.Get(2*LargeAlign, false)
+		p.Put(a)
+		p.Put(b)
+		if c := p.Get(LargeAlign+1, false); &c[0] != &a[0] || len(c) != LargeAlign+1 {
+			t.Fatalf("expect: cached buffer reused with len=%d; got: len=%d", LargeAlign+1, len(c))
+		}
+		if c := p.Get(2*LargeAlign, false); &c[0] == &b[0] {
+			t.Fatalf("expect: buffer beyond the retention bound dropped")
+		}
+		if c := p.Get(5*LargeAlign, false); cap(c) != 5*LargeAlign {
+			t.Fatalf("expect: cap=%d; got: cap=%d", 5*LargeAlign, cap(c))
+		}
+		if st := p.LargeStats(); st.Gets != 5 || st.Puts != 2 || st.Misses != 4 || st.Drops != 1 {
+			t.Fatalf("expect: gets=5, puts=2, misses=4, drops=1; got: %+v", st)
+		}
+	})
+	t.Run("strict", func(t *testing.T) {
+		p := MustNew([]int{1024}, WithStrict())
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrForeignBuf) {
+				t.Fatalf("expect: %v; got: %v", ErrForeignBuf, err)
+			}
+		}()
+		p.Put(make([]byte, 5000))
+	})
+}
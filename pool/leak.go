@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"runtime/debug"
+)
+
+// Leak describes a buffer obtained from a Pool and never given back.
+type Leak struct {
+	// Size is the capacity of the buffer.
+	Size int
+
+	// Stack is the stack trace of the goroutine that got the buffer.
+	Stack string
+}
+
+// track records the allocation stack of buf. It is a no-op unless the package
+// is built with the pooldebug tag.
+func (p *Pool) track(buf []byte) {
+	if !debugBuild || cap(buf) == 0 {
+		return
+	}
+	l := Leak{Size: cap(buf), Stack: string(debug.Stack())}
+	p.leakMu.Lock()
+	p.outstanding[&buf[:1][0]] = l
+	p.leakMu.Unlock()
+}
+
+// untrack forgets buf once it is back in the pool. It reports whether buf
+// was outstanding, which is always true unless the package is built with the
+// pooldebug tag.
+func (p *Pool) untrack(buf []byte) bool {
+	if !debugBuild || cap(buf) == 0 {
+		return true
+	}
+	key := &buf[:1][0]
+	p.leakMu.Lock()
+	_, ok := p.outstanding[key]
+	delete(p.outstanding, key)
+	p.leakMu.Unlock()
+	return ok
+}
+
+// Leaks returns the buffers handed out by Get and not returned by Put,
+// together with the stack that got them. It always returns nil unless the
+// package is built with the pooldebug tag, e.g.:
+//
+//	go test -tags pooldebug ./...
+func (p *Pool) Leaks() []Leak {
+	if !debugBuild {
+		return nil
+	}
+	p.leakMu.Lock()
+	defer p.leakMu.Unlock()
+	res := make([]Leak, 0, len(p.outstanding))
+	for _, l := range p.outstanding {
+		res = append(res, l)
+	}
+	return res
+}
+
+// Leaks returns the outstanding buffers of Default.
+func Leaks() []Leak {
+	return Default.Leaks()
+}
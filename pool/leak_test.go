@@ -0,0 +1,42 @@
+//go:build pooldebug
+// +build pooldebug
+
+package pool
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLeaks(t *testing.T) {
+	before := len(Leaks())
+	buf := GetBuf(64, true)
+	var found bool
+	for _, l := range Leaks() {
+		if l.Size == 64 && strings.Contains(l.Stack, "TestLeaks") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expect: a 64 bytes leak from TestLeaks; got: %+v", Leaks())
+	}
+	PutBuf(buf)
+	if n := len(Leaks()); n != before {
+		t.Fatalf("expect: %d leaks after PutBuf; got: %d", before, n)
+	}
+}
+
+func TestDoublePut(t *testing.T) {
+	SetStrict(true)
+	defer SetStrict(false)
+	buf := GetBuf(64, false)
+	PutBuf(buf)
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrNotOutstanding) {
+			t.Fatalf("expect: %v; got: %v", ErrNotOutstanding, err)
+		}
+	}()
+	PutBuf(buf)
+}
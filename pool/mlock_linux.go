@@ -0,0 +1,21 @@
+package pool
+
+import (
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// lock locks the pages of buf in memory until buf is collected.
+func lock(buf []byte) {
+	if len(buf) == 0 || unix.Mlock(buf) != nil {
+		return
+	}
+	ptr := unsafe.SliceData(buf)
+	// The cleanup only knows the address range: a pointer would keep buf
+	// alive, and rebuilding one from a collected object is invalid.
+	runtime.AddCleanup(ptr, func(r [2]uintptr) {
+		unix.Syscall(unix.SYS_MUNLOCK, r[0], r[1], 0)
+	}, [2]uintptr{uintptr(unsafe.Pointer(ptr)), uintptr(len(buf))})
+}
@@ -0,0 +1,137 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Of is a pool of *T values. Every value is reset before it is kept, so Get
+// always returns a clean value. An Of is safe for concurrent use.
+type Of[T any] struct {
+	pool sync.Pool
+	hook func(*T)
+
+	size    func(*T) int
+	maxSize int
+
+	statsOn atomic.Bool
+	gets    atomic.Uint64
+	puts    atomic.Uint64
+	misses  atomic.Uint64
+	drops   atomic.Uint64
+}
+
+// ObjectStats is a snapshot of the counters of an Of.
+type ObjectStats struct {
+	// Gets counts values handed out by Get.
+	Gets uint64
+
+	// Puts counts values given back through Put.
+	Puts uint64
+
+	// Misses counts gets that found the pool empty and allocated.
+	Misses uint64
+
+	// Drops counts puts of values exceeding the maximum size.
+	Drops uint64
+}
+
+// Hits returns the number of gets served from the pool.
+func (s ObjectStats) Hits() uint64 {
+	if s.Misses > s.Gets {
+		return 0
+	}
+	return s.Gets - s.Misses
+}
+
+// OfOption configures an Of created by NewOf.
+type OfOption[T any] func(*Of[T])
+
+// WithMaxSize drops values whose size, as measured by size, exceeds max
+// instead of keeping them, e.g. structs holding a grown slice:
+//
+//	pool.WithMaxSize(func(r *Request) int { return cap(r.Body) }, 64<<10)
+func WithMaxSize[T any](size func(*T) int, max int) OfOption[T] {
+	return func(o *Of[T]) {
+		o.size = size
+		o.maxSize = max
+	}
+}
+
+// WithObjectStats switches the counters of an Of on from the start.
+func WithObjectStats[T any]() OfOption[T] {
+	return func(o *Of[T]) {
+		o.statsOn.Store(true)
+	}
+}
+
+// NewOf creates a pool of *T values. reset is required: it is called on every
+// value given back through Put and must clear the fields of the value.
+func NewOf[T any](reset func(*T), opts ...OfOption[T]) *Of[T] {
+	if reset == nil {
+		panic("pool.NewOf: nil reset hook")
+	}
+	o := &Of[T]{hook: reset}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Get returns a reset value, allocating a new one if the pool is empty.
+func (o *Of[T]) Get() *T {
+	stats := o.statsOn.Load()
+	if stats {
+		o.gets.Add(1)
+	}
+	if v, ok := o.pool.Get().(*T); ok {
+		return v
+	}
+	if stats {
+		o.misses.Add(1)
+	}
+	return new(T)
+}
+
+// Put resets v and keeps it for a later Get, unless it exceeds the maximum
+// size set with WithMaxSize. A nil v is ignored.
+func (o *Of[T]) Put(v *T) {
+	if v == nil {
+		return
+	}
+	stats := o.statsOn.Load()
+	if stats {
+		o.puts.Add(1)
+	}
+	if o.size != nil && o.size(v) > o.maxSize {
+		if stats {
+			o.drops.Add(1)
+		}
+		return
+	}
+	o.hook(v)
+	o.pool.Put(v)
+}
+
+// EnableStats switches the counters on or off.
+func (o *Of[T]) EnableStats(on bool) {
+	o.statsOn.Store(on)
+}
+
+// Stats returns a snapshot of the counters.
+func (o *Of[T]) Stats() ObjectStats {
+	return ObjectStats{
+		Gets:   o.gets.Load(),
+		Puts:   o.puts.Load(),
+		Misses: o.misses.Load(),
+		Drops:  o.drops.Load(),
+	}
+}
+
+// ResetStats clears the counters.
+func (o *Of[T]) ResetStats() {
+	o.gets.Store(0)
+	o.puts.Store(0)
+	o.misses.Store(0)
+	o.drops.Store(0)
+}
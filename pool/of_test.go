@@ -0,0 +1,41 @@
+package pool
+
+import "testing"
+
+type request struct {
+	ID   int
+	Body []byte
+}
+
+func TestOf(t *testing.T) {
+	o := NewOf(func(r *request) {
+		r.ID = 0
+		r.Body = r.Body[:0]
+	}, WithMaxSize(func(r *request) int { return cap(r.Body) }, 1024), WithObjectStats[request]())
+
+	r := o.Get()
+	r.ID = 7
+	r.Body = append(r.Body, "hello"...)
+	o.Put(r)
+
+	big := o.Get()
+	big.Body = make([]byte, 2048)
+	o.Put(big)
+	o.Put(nil)
+
+	for i := 0; i < 2; i++ {
+		if r := o.Get(); r.ID != 0 || len(r.Body) != 0 || cap(r.Body) > 1024 {
+			t.Fatalf("expect: reset value; got: id=%d, len=%d, cap=%d", r.ID, len(r.Body), cap(r.Body))
+		}
+	}
+	if st := o.Stats(); st.Gets != 4 || st.Puts != 2 || st.Drops != 1 || st.Hits()+st.Misses != 4 {
+		t.Fatalf("expect: gets=4, puts=2, drops=1; got: %+v", st)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect: panic on nil reset hook")
+		}
+	}()
+	NewOf[request](nil)
+}
@@ -1,7 +1,11 @@
 package pool
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -10,42 +14,350 @@ const (
 
 var class_to_size = [_NumSizeClasses]int{32, 64, 128, 256, 512, 1024, 2048, 4096, 10240, 20480, 40960, 81920, 163840, 327680}
 
-func getPoolSizeClass(size int) int {
-	for i := 0; i < _NumSizeClasses; i++ {
-		if class_to_size[i] >= size {
-			return i
+// ErrInvalidClasses is returned by New when the class ladder is empty, not
+// strictly increasing or holds a non-positive size.
+var ErrInvalidClasses = errors.New("pool: size classes must be positive and strictly increasing")
+
+// ErrForeignBuf is reported in strict mode when Put is given a buffer whose
+// capacity is not the size of any class, e.g. a slice allocated elsewhere,
+// one resliced from the front or one grown by append.
+var ErrForeignBuf = errors.New("pool: buffer does not belong to any size class")
+
+// ErrNotOutstanding is reported in strict mode by pooldebug builds when Put
+// is given a buffer that is not handed out, e.g. on a double put.
+var ErrNotOutstanding = errors.New("pool: buffer is not outstanding")
+
+type sizeClass struct {
+	size    int
+	backend backend
+	classCounters
+
+	// free and victim estimate the buffers kept by the backend, see budget.
+	free   atomic.Int64
+	victim atomic.Int64
+}
+
+// table is a ladder of size classes. It is never modified once built;
+// Retune swaps it as a whole.
+type table struct {
+	sizes   []int
+	classes []sizeClass
+
+	// retired holds the sizes of the previous ladders, sorted, so that
+	// their buffers are not mistaken for foreign ones.
+	retired []int
+
+	// retiredMax is the smallest largest class of the previous ladders, 0
+	// if none: the multiples of LargeAlign beyond it may be oversized
+	// buffers handed out by one of them.
+	retiredMax int
+}
+
+// class returns the smallest class able to hold size bytes, or -1 if size
+// exceeds the largest class.
+func (t *table) class(size int) int {
+	i := sort.SearchInts(t.sizes, size)
+	if i == len(t.sizes) {
+		return -1
+	}
+	return i
+}
+
+// maxSize returns the size of the largest class.
+func (t *table) maxSize() int {
+	return t.sizes[len(t.sizes)-1]
+}
+
+// classOfCap returns the class whose size is exactly c, or -1 if there is
+// none.
+func (t *table) classOfCap(c int) int {
+	i := sort.SearchInts(t.sizes, c)
+	if i == len(t.sizes) || t.sizes[i] != c {
+		return -1
+	}
+	return i
+}
+
+// isRetiredLarge reports whether c may be the capacity of an oversized
+// buffer of a previous ladder.
+func (t *table) isRetiredLarge(c int) bool {
+	return t.retiredMax > 0 && c > t.retiredMax && c%LargeAlign == 0
+}
+
+// isRetired reports whether c is the size of a class of a previous ladder.
+func (t *table) isRetired(c int) bool {
+	i := sort.SearchInts(t.retired, c)
+	return i < len(t.retired) && t.retired[i] == c
+}
+
+// Pool hands out byte buffers from a ladder of size classes, each class
+// backed by its own sync.Pool, or by a slab for small classes if configured
+// with WithSlab. A Pool is safe for concurrent use.
+type Pool struct {
+	tab    atomic.Pointer[table]
+	tuneMu sync.Mutex
+
+	large *largeCache
+	arena *Arena
+
+	slabMax       int
+	slabHighWater int
+
+	budget   int64
+	onDrop   func(size int)
+	retained atomic.Int64
+
+	hist *histogram
+
+	wipe  bool
+	mlock bool
+
+	statsOn atomic.Bool
+	strict  atomic.Bool
+
+	leakMu      sync.Mutex
+	outstanding map[*byte]Leak
+}
+
+// Option configures a Pool created by New.
+type Option func(*Pool)
+
+// WithStats switches the per-class counters on from the start.
+func WithStats() Option {
+	return func(p *Pool) {
+		p.statsOn.Store(true)
+	}
+}
+
+// WithStrict switches strict mode on from the start, see SetStrict.
+func WithStrict() Option {
+	return func(p *Pool) {
+		p.strict.Store(true)
+	}
+}
+
+func validClasses(classes []int) bool {
+	if len(classes) == 0 {
+		return false
+	}
+	for i, size := range classes {
+		if size <= 0 || (i > 0 && size <= classes[i-1]) {
+			return false
 		}
 	}
-	return _NumSizeClasses - 1
+	return true
 }
 
-var pools = []*sync.Pool{}
+// New creates a Pool serving the given class sizes, which must be positive
+// and strictly increasing. The slice is copied.
+func New(classes []int, opts ...Option) (*Pool, error) {
+	if !validClasses(classes) {
+		return nil, ErrInvalidClasses
+	}
+
+	p := &Pool{
+		large:       &largeCache{},
+		outstanding: map[*byte]Leak{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.slabMax > 0 && p.slabHighWater <= 0 {
+		return nil, ErrInvalidSlab
+	}
+	p.tab.Store(p.newTable(classes, nil))
+	p.watchGC()
+	return p, nil
+}
 
-func init() {
-	pools = make([]*sync.Pool, _NumSizeClasses)
-	for i := 0; i < _NumSizeClasses; i++ {
-		tmpSize := class_to_size[i]
-		pools[i] = &sync.Pool{
-			New: func() interface{} {
-				return make([]byte, tmpSize)
-			},
+// newTable builds the classes for the given sizes with the backends
+// configured for p.
+func (p *Pool) newTable(sizes, retired []int) *table {
+	t := &table{
+		sizes:   append([]int(nil), sizes...),
+		classes: make([]sizeClass, len(sizes)),
+		retired: retired,
+	}
+	for i := range t.classes {
+		c := &t.classes[i]
+		c.size = sizes[i]
+		if c.size <= p.slabMax {
+			c.backend = newSlab(c.size, p.slabHighWater)
+		} else {
+			c.backend = &syncBackend{}
 		}
 	}
+	return t
 }
 
-// GetBuf: get one buffer from the proper pool
-func GetBuf(size int, zero bool) []byte {
-	class := getPoolSizeClass(size)
-	p := pools[class].Get()
-	buf := p.([]byte)
+// MustNew is like New but panics if the class ladder is invalid.
+func MustNew(classes []int, opts ...Option) *Pool {
+	p, err := New(classes, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Default is the Pool used by the package level functions.
+var Default = MustNew(class_to_size[:])
+
+// Classes returns a copy of the class sizes served by p.
+func (p *Pool) Classes() []int {
+	return append([]int(nil), p.tab.Load().sizes...)
+}
+
+// maxSize returns the size of the largest class.
+func (p *Pool) maxSize() int {
+	return p.tab.Load().maxSize()
+}
+
+// SetStrict switches strict mode on or off. In strict mode Put panics with an
+// error wrapping ErrForeignBuf or ErrNotOutstanding instead of silently
+// dropping a misused buffer.
+func (p *Pool) SetStrict(on bool) {
+	p.strict.Store(on)
+}
+
+func (p *Pool) misuse(err error, buf []byte) {
+	if p.strict.Load() {
+		panic(fmt.Errorf("%w: len=%d, cap=%d", err, len(buf), cap(buf)))
+	}
+}
+
+// Get returns a buffer able to hold size bytes. The buffer has the length of
+// its class, or zero length if zero is true. Sizes beyond the largest class
+// are served from the arena of p if any, or according to its LargePolicy,
+// with a length of exactly size bytes.
+func (p *Pool) Get(size int, zero bool) []byte {
+	if p.hist != nil {
+		p.record(size)
+	}
+	var buf []byte
+	t := p.tab.Load()
+	if class := t.class(size); class >= 0 {
+		c := &t.classes[class]
+		if p.statsOn.Load() {
+			c.gets.Add(1)
+		}
+		if buf = c.backend.get(); buf == nil {
+			if p.statsOn.Load() {
+				c.misses.Add(1)
+			}
+			buf = p.alloc(c.size)
+		} else if p.budget > 0 || p.statsOn.Load() {
+			p.taken(c)
+		}
+	} else if p.arena != nil {
+		buf = p.arena.Get(size, false)
+		if p.statsOn.Load() {
+			p.large.gets.Add(1)
+			p.large.inUse.Add(int64(cap(buf)))
+		}
+	} else {
+		var hit bool
+		buf, hit = p.large.get(size, p.statsOn.Load())
+		if hit && p.budget > 0 {
+			p.release(int64(cap(buf)))
+		}
+		if !hit && p.mlock {
+			lock(buf)
+		}
+		if p.statsOn.Load() {
+			p.large.inUse.Add(int64(cap(buf)))
+		}
+	}
+	p.track(buf)
 	if zero {
 		return buf[:0]
 	}
 	return buf
 }
 
-// PutBuf: put the buffer back to the proper pool
+// Put gives buf back to p. The class is chosen by the capacity of buf, which
+// is restored to its full length. Buffers whose capacity is not exactly a
+// class size, nor a multiple of LargeAlign beyond the largest class, are
+// dropped. A secure pool wipes buf first, whatever becomes of it.
+//
+// After Retune, the buffers of the previous ladders, oversized ones
+// included, are dropped.
+func (p *Pool) Put(buf []byte) {
+	if p.wipe {
+		wipe(buf[:cap(buf)])
+	}
+	t := p.tab.Load()
+	class := t.classOfCap(cap(buf))
+	if class < 0 && (cap(buf) <= t.maxSize() || cap(buf)%LargeAlign != 0) {
+		if t.isRetired(cap(buf)) {
+			p.untrack(buf)
+			return
+		}
+		if t.isRetiredLarge(cap(buf)) && p.untrack(buf) {
+			if p.statsOn.Load() {
+				p.large.puts.Add(1)
+				p.large.inUse.Add(-int64(cap(buf)))
+			}
+			if p.arena != nil {
+				p.arena.Put(buf)
+			}
+			return
+		}
+		p.misuse(ErrForeignBuf, buf)
+		return
+	}
+	if !p.untrack(buf) {
+		p.misuse(ErrNotOutstanding, buf)
+		return
+	}
+	if class < 0 && p.arena != nil {
+		if p.statsOn.Load() {
+			p.large.puts.Add(1)
+			p.large.inUse.Add(-int64(cap(buf)))
+		}
+		p.arena.Put(buf)
+		return
+	}
+	if class < 0 {
+		p.putLarge(buf)
+		return
+	}
+	c := &t.classes[class]
+	stats := p.statsOn.Load()
+	if stats {
+		c.puts.Add(1)
+	}
+	if p.budget > 0 && !p.reserve(t, c.size, class) {
+		if stats {
+			c.drops.Add(1)
+		}
+		p.dropped(c.size)
+		return
+	}
+	if !c.backend.put(buf[:cap(buf)]) {
+		if stats {
+			c.drops.Add(1)
+		}
+		if p.budget > 0 {
+			p.release(int64(c.size))
+		}
+		return
+	}
+	if p.budget > 0 || stats {
+		c.free.Add(1)
+	}
+}
+
+// GetBuf: get one buffer from the proper pool of Default
+func GetBuf(size int, zero bool) []byte {
+	return Default.Get(size, zero)
+}
+
+// PutBuf: put the buffer back to the proper pool of Default
 func PutBuf(buf []byte) {
-	class := getPoolSizeClass(len(buf))
-	pools[class].Put(buf)
+	Default.Put(buf)
+}
+
+// SetStrict switches strict mode of Default on or off.
+func SetStrict(on bool) {
+	Default.SetStrict(on)
 }
@@ -1,6 +1,9 @@
 package pool
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestGetBuf(t *testing.T) {
 	t.Run("nozero", func(t *testing.T) {
@@ -42,3 +45,94 @@ func TestGetBuf(t *testing.T) {
 		}
 	})
 }
+
+func TestPutBufMisuse(t *testing.T) {
+	t.Run("appended", func(t *testing.T) {
+		buf := GetBuf(64, true)
+		buf = append(buf, "abc"...)
+		PutBuf(buf)
+		buf = GetBuf(64, false)
+		if len(buf) != 64 || cap(buf) != 64 {
+			t.Fatalf("expect: len=64, cap=64; got: len=%d, cap=%d", len(buf), cap(buf))
+		}
+		PutBuf(buf)
+	})
+	t.Run("foreign", func(t *testing.T) {
+		PutBuf(make([]byte, 10))
+		for i := 0; i < 8; i++ {
+			buf := GetBuf(32, false)
+			if len(buf) != 32 || cap(buf) != 32 {
+				t.Fatalf("expect: len=32, cap=32; got: len=%d, cap=%d", len(buf), cap(buf))
+			}
+			defer PutBuf(buf)
+		}
+	})
+	t.Run("resliced", func(t *testing.T) {
+		buf := GetBuf(128, false)
+		PutBuf(buf[16:])
+		for i := 0; i < 8; i++ {
+			buf := GetBuf(100, false)
+			if len(buf) != 128 || cap(buf) != 128 {
+				t.Fatalf("expect: len=128, cap=128; got: len=%d, cap=%d", len(buf), cap(buf))
+			}
+			defer PutBuf(buf)
+		}
+	})
+	t.Run("strict", func(t *testing.T) {
+		SetStrict(true)
+		defer SetStrict(false)
+		cases := map[string][]byte{
+			"foreign":  make([]byte, 10),
+			"resliced": GetBuf(128, false)[16:],
+			"nil":      nil,
+		}
+		for name, buf := range cases {
+			func() {
+				defer func() {
+					err, _ := recover().(error)
+					if !errors.Is(err, ErrForeignBuf) {
+						t.Fatalf("case: %s  expect: %v; got: %v", name, ErrForeignBuf, err)
+					}
+				}()
+				PutBuf(buf)
+			}()
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	invalid := map[string][]int{
+		"empty":      nil,
+		"zero":       {0, 16},
+		"unsorted":   {64, 32},
+		"duplicated": {32, 32},
+	}
+	for name, classes := range invalid {
+		if _, err := New(classes); err != ErrInvalidClasses {
+			t.Fatalf("case: %s  expect: %v; got: %v", name, ErrInvalidClasses, err)
+		}
+	}
+
+	p := MustNew([]int{8, 16, 4096}, WithStats())
+	cases := map[string]struct {
+		Size   int
+		ExpCap int
+	}{
+		"s1": {1, 8},
+		"s2": {9, 16},
+		"s3": {17, 4096},
+	}
+	for name, cas := range cases {
+		buf := p.Get(cas.Size, false)
+		if len(buf) != cas.ExpCap || cap(buf) != cas.ExpCap {
+			t.Fatalf("case: %s  expect: len=%d, cap=%d; got: len=%d, cap=%d", name, cas.ExpCap, cas.ExpCap, len(buf), cap(buf))
+		}
+		p.Put(buf)
+	}
+	if st := p.Stats(); len(st) != 3 || st[2].Gets != 1 || st[2].Puts != 1 {
+		t.Fatalf("expect: 3 classes with 1 get and put in the last; got: %+v", st)
+	}
+	if st := Stats(); st[0].Gets != 0 {
+		t.Fatalf("expect: Default untouched; got: %+v", st[0])
+	}
+}
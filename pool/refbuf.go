@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReleased is the panic value of pooldebug builds when a RefBuf is used
+// after its last reference was released, including a double release.
+var ErrReleased = errors.New("pool: use of released RefBuf")
+
+// RefBuf is a reference-counted buffer shared by several owners, e.g. one
+// encoded message written to many connections. Every owner calls Release
+// once done; the last one gives the buffer back to its pool.
+//
+// The counter is updated atomically. Built with the pooldebug tag, RefBuf
+// panics with ErrReleased on use after the last release and on double
+// release.
+type RefBuf struct {
+	p    *Pool
+	buf  []byte
+	refs atomic.Int32
+}
+
+// NewRefBuf returns a RefBuf of size bytes from Default, holding one
+// reference.
+func NewRefBuf(size int) *RefBuf {
+	return Default.NewRefBuf(size)
+}
+
+// NewRefBuf returns a RefBuf of size bytes from p, holding one reference.
+func (p *Pool) NewRefBuf(size int) *RefBuf {
+	b := &RefBuf{p: p, buf: p.Get(size, false)[:size]}
+	b.refs.Store(1)
+	return b
+}
+
+func (b *RefBuf) check() {
+	if debugBuild && b.refs.Load() <= 0 {
+		panic(ErrReleased)
+	}
+}
+
+// Bytes returns the buffer. It must not be used after Release.
+func (b *RefBuf) Bytes() []byte {
+	b.check()
+	return b.buf
+}
+
+// Refs returns the current number of references.
+func (b *RefBuf) Refs() int {
+	return int(b.refs.Load())
+}
+
+// Retain adds a reference and returns b, so that handing the buffer to a new
+// owner reads as:
+//
+//	go send(conn, msg.Retain())
+func (b *RefBuf) Retain() *RefBuf {
+	if n := b.refs.Add(1); debugBuild && n <= 1 {
+		panic(ErrReleased)
+	}
+	return b
+}
+
+// Release drops a reference. Dropping the last one gives the buffer back to
+// its pool.
+func (b *RefBuf) Release() {
+	n := b.refs.Add(-1)
+	if n == 0 {
+		b.p.Put(b.buf)
+		return
+	}
+	if debugBuild && n < 0 {
+		panic(ErrReleased)
+	}
+}
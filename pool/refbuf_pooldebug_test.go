@@ -0,0 +1,26 @@
+//go:build pooldebug
+// +build pooldebug
+
+package pool
+
+import "testing"
+
+func TestRefBufReleased(t *testing.T) {
+	cases := map[string]func(b *RefBuf){
+		"bytes":   func(b *RefBuf) { b.Bytes() },
+		"retain":  func(b *RefBuf) { b.Retain() },
+		"release": func(b *RefBuf) { b.Release() },
+	}
+	for name, use := range cases {
+		b := NewRefBuf(10)
+		b.Release()
+		func() {
+			defer func() {
+				if err := recover(); err != ErrReleased {
+					t.Fatalf("case: %s  expect: %v; got: %v", name, ErrReleased, err)
+				}
+			}()
+			use(b)
+		}()
+	}
+}
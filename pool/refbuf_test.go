@@ -0,0 +1,32 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRefBuf(t *testing.T) {
+	p := MustNew([]int{64, 128}, WithStats())
+	b := p.NewRefBuf(100)
+	if len(b.Bytes()) != 100 || b.Refs() != 1 {
+		t.Fatalf("expect: len=100, refs=1; got: len=%d, refs=%d", len(b.Bytes()), b.Refs())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(b *RefBuf) {
+			defer wg.Done()
+			defer b.Release()
+			_ = b.Bytes()[0]
+		}(b.Retain())
+	}
+	wg.Wait()
+	if st := p.Stats()[1]; b.Refs() != 1 || st.InUse() != 1 {
+		t.Fatalf("expect: refs=1, inuse=1; got: refs=%d, inuse=%d", b.Refs(), st.InUse())
+	}
+	b.Release()
+	if st := p.Stats()[1]; st.InUse() != 0 {
+		t.Fatalf("expect: buffer returned; got: inuse=%d", st.InUse())
+	}
+}
@@ -0,0 +1,188 @@
+package pool
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrRingFull is returned when a Ring holds its maximum size of unread data.
+var ErrRingFull = errors.New("pool: ring is full")
+
+// Ring is a read buffer for streaming protocols: read from a connection into
+// it, Peek at a frame, Discard it once parsed. The unread data is kept
+// contiguous by moving it to the front when the space behind it runs out.
+// The storage comes from a Pool and steps through its classes when a frame
+// does not fit, up to a maximum size. It shrinks back to its initial class
+// once idle, i.e. after RingShrinkAfter drains in a row that the initial
+// class would have held, so that a stream of large frames keeps its storage.
+type Ring struct {
+	p        *Pool
+	buf      []byte
+	r, w     int
+	min, max int
+	initial  int // capacity of the initial class
+
+	peak int // most bytes buffered since the last drain
+	idle int // drains in a row that fitted the initial class
+}
+
+// RingShrinkAfter is the number of drains in a row fitting the initial class
+// after which a Ring shrinks back to it.
+const RingShrinkAfter = 16
+
+// NewRing returns an empty Ring backed by Default, starting with room for
+// size bytes and growing up to max bytes.
+func NewRing(size, max int) *Ring {
+	return Default.NewRing(size, max)
+}
+
+// NewRing returns an empty Ring backed by p, starting with room for size
+// bytes and growing up to max bytes.
+func (p *Pool) NewRing(size, max int) *Ring {
+	r := &Ring{p: p, min: size, max: max}
+	r.buf = p.Get(size, false)
+	r.initial = len(r.buf)
+	return r
+}
+
+// Buffered returns the number of unread bytes.
+func (r *Ring) Buffered() int { return r.w - r.r }
+
+// Cap returns the capacity of the underlying storage.
+func (r *Ring) Cap() int { return len(r.buf) }
+
+// Peek returns the next n unread bytes without consuming them. If fewer
+// bytes are buffered it returns them with io.ErrShortBuffer. The slice is
+// only valid until the next read or Discard.
+func (r *Ring) Peek(n int) ([]byte, error) {
+	if n < 0 {
+		panic("pool.Ring.Peek: negative count")
+	}
+	if n > r.Buffered() {
+		return r.buf[r.r:r.w], io.ErrShortBuffer
+	}
+	return r.buf[r.r : r.r+n], nil
+}
+
+// Discard consumes the next n unread bytes, or all of them if fewer are
+// buffered, and returns the number of bytes discarded.
+func (r *Ring) Discard(n int) int {
+	if n < 0 {
+		panic("pool.Ring.Discard: negative count")
+	}
+	if n > r.Buffered() {
+		n = r.Buffered()
+	}
+	r.r += n
+	if n > 0 && r.r == r.w {
+		r.r, r.w = 0, 0
+		if r.peak <= r.initial {
+			r.idle++
+		} else {
+			r.idle = 0
+		}
+		r.peak = 0
+		if r.idle >= RingShrinkAfter {
+			r.Shrink()
+		}
+	}
+	return n
+}
+
+// Shrink goes back to the initial class if the ring is drained and grew
+// beyond it. It reports whether the ring is at its initial class.
+func (r *Ring) Shrink() bool {
+	if r.Buffered() > 0 {
+		return len(r.buf) <= r.initial
+	}
+	r.idle = 0
+	if len(r.buf) > r.initial {
+		r.p.Put(r.buf)
+		r.buf = r.p.Get(r.min, false)
+	}
+	return true
+}
+
+// limit returns the end of the usable storage, which may be shorter than
+// its class.
+func (r *Ring) limit() int {
+	if len(r.buf) > r.max {
+		return r.max
+	}
+	return len(r.buf)
+}
+
+// space makes room behind the unread data, moving it to the front or
+// growing the storage, and fails with ErrRingFull at the maximum size.
+func (r *Ring) space() error {
+	if r.buf == nil {
+		r.buf = r.p.Get(r.min, false)
+	}
+	if r.w < r.limit() {
+		return nil
+	}
+	if r.r > 0 {
+		n := copy(r.buf, r.buf[r.r:r.w])
+		r.r, r.w = 0, n
+		return nil
+	}
+	if r.limit() == r.max {
+		return ErrRingFull
+	}
+	size := 2 * len(r.buf)
+	if size > r.max {
+		size = r.max
+	}
+	buf := r.p.Get(size, false)
+	r.w = copy(buf, r.buf[r.r:r.w])
+	r.r = 0
+	r.p.Put(r.buf)
+	r.buf = buf
+	return nil
+}
+
+// Fill reads once from rd into the ring and returns the number of bytes
+// read. It fails with ErrRingFull, reading nothing, when the ring holds its
+// maximum size.
+func (r *Ring) Fill(rd io.Reader) (int, error) {
+	if err := r.space(); err != nil {
+		return 0, err
+	}
+	n, err := rd.Read(r.buf[r.w:r.limit()])
+	if n < 0 {
+		panic("pool.Ring.Fill: reader returned negative count")
+	}
+	r.w += n
+	if b := r.Buffered(); b > r.peak {
+		r.peak = b
+	}
+	return n, err
+}
+
+// ReadFrom reads from rd until EOF, or until the ring holds its maximum size
+// in which case it fails with ErrRingFull. Any error other than io.EOF is
+// returned.
+func (r *Ring) ReadFrom(rd io.Reader) (int64, error) {
+	var total int64
+	for {
+		n, err := r.Fill(rd)
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Release gives the storage back to the pool and empties the ring. A
+// released Ring may be used again.
+func (r *Ring) Release() {
+	if r.buf != nil {
+		r.p.Put(r.buf)
+	}
+	r.buf = nil
+	r.r, r.w = 0, 0
+	r.peak, r.idle = 0, 0
+}
@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// frames encodes payloads as 2 bytes big endian length followed by data.
+func frames(payloads ...string) []byte {
+	var b bytes.Buffer
+	for _, s := range payloads {
+		binary.Write(&b, binary.BigEndian, uint16(len(s)))
+		b.WriteString(s)
+	}
+	return b.Bytes()
+}
+
+func TestRing(t *testing.T) {
+	p := MustNew([]int{16, 64, 256}, WithStats())
+	r := p.NewRing(10, 128)
+	if r.Cap() != 16 {
+		t.Fatalf("expect: cap=16; got: cap=%d", r.Cap())
+	}
+
+	payloads := []string{"hello", "a much longer frame of more than sixteen bytes", "", "bye"}
+	src := iotest.OneByteReader(bytes.NewReader(frames(payloads...)))
+	var got []string
+	for {
+		hdr, err := r.Peek(2)
+		if err == nil {
+			n := int(binary.BigEndian.Uint16(hdr))
+			if frame, err := r.Peek(2 + n); err == nil {
+				got = append(got, string(frame[2:]))
+				r.Discard(2 + n)
+				continue
+			}
+		}
+		if _, err := r.Fill(src); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Fill: %v", err)
+		}
+	}
+	if len(got) != len(payloads) {
+		t.Fatalf("expect: %q; got: %q", payloads, got)
+	}
+	for i := range got {
+		if got[i] != payloads[i] {
+			t.Fatalf("expect: %q; got: %q", payloads, got)
+		}
+	}
+	if r.Buffered() != 0 || r.Cap() != 64 {
+		t.Fatalf("expect: drained ring kept at cap=64; got: buffered=%d, cap=%d", r.Buffered(), r.Cap())
+	}
+	if !r.Shrink() || r.Cap() != 16 {
+		t.Fatalf("expect: ring shrunk to cap=16; got: cap=%d", r.Cap())
+	}
+
+	if n, err := r.ReadFrom(bytes.NewReader(make([]byte, 200))); err != ErrRingFull || n != 128 {
+		t.Fatalf("expect: 128 bytes then %v; got: n=%d, err=%v", ErrRingFull, n, err)
+	}
+	if b, err := r.Peek(200); err != io.ErrShortBuffer || len(b) != 128 {
+		t.Fatalf("expect: 128 bytes and %v; got: len=%d, err=%v", io.ErrShortBuffer, len(b), err)
+	}
+	if n := r.Discard(1000); n != 128 {
+		t.Fatalf("expect: 128 discarded; got: %d", n)
+	}
+
+	r.Release()
+	for _, st := range p.Stats() {
+		if st.InUse() != 0 {
+			t.Fatalf("expect: storage returned; got: %+v", st)
+		}
+	}
+}
+
+func TestRingShrinkIdle(t *testing.T) {
+	p := MustNew([]int{16, 64, 256}, WithStats())
+	r := p.NewRing(16, 256)
+	large := frames(string(make([]byte, 40)))
+	small := frames("hi")
+
+	// A stream of large frames keeps the grown storage.
+	for range 2 * RingShrinkAfter {
+		r.ReadFrom(bytes.NewReader(large))
+		r.Discard(len(large))
+	}
+	if r.Cap() != 64 {
+		t.Fatalf("expect: cap=64; got: cap=%d", r.Cap())
+	}
+	if st := p.Stats()[1]; st.Gets != 1 {
+		t.Fatalf("expect: storage grown once; got: %+v", st)
+	}
+
+	// Discarding nothing is not a drain.
+	for range 2 * RingShrinkAfter {
+		r.Discard(0)
+	}
+	if r.Cap() != 64 {
+		t.Fatalf("expect: cap=64 after empty discards; got: cap=%d", r.Cap())
+	}
+
+	for i := 1; i <= RingShrinkAfter; i++ {
+		r.ReadFrom(bytes.NewReader(small))
+		r.Discard(len(small))
+		exp := 64
+		if i == RingShrinkAfter {
+			exp = 16
+		}
+		if r.Cap() != exp {
+			t.Fatalf("expect: cap=%d after %d small frames; got: cap=%d", exp, i, r.Cap())
+		}
+	}
+
+	// Shrink keeps buffered data.
+	r.ReadFrom(bytes.NewReader(large))
+	if r.Shrink() || r.Buffered() != len(large) {
+		t.Fatalf("expect: no shrink with %d bytes buffered; got: buffered=%d", len(large), r.Buffered())
+	}
+	r.Release()
+}
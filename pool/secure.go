@@ -0,0 +1,43 @@
+package pool
+
+// WithWipe zeroes every buffer given back to the pool, so that no Get ever
+// observes the content left by a previous user.
+func WithWipe() Option {
+	return func(p *Pool) {
+		p.wipe = true
+	}
+}
+
+// WithMlock locks in memory the buffers allocated by the pool, keeping them
+// out of swap, and unlocks them once collected. Locking works on whole pages
+// which the Go heap may share between objects, so it is a best effort. It is
+// only supported on Linux and is ignored elsewhere.
+func WithMlock() Option {
+	return func(p *Pool) {
+		p.mlock = true
+	}
+}
+
+// NewSecure creates a Pool for secrets such as keys and tokens: buffers are
+// wiped when given back, see WithWipe. Add WithMlock to keep them out of
+// swap.
+func NewSecure(classes []int, opts ...Option) (*Pool, error) {
+	return New(classes, append([]Option{WithWipe()}, opts...)...)
+}
+
+// alloc allocates a buffer of size bytes for a class.
+func (p *Pool) alloc(size int) []byte {
+	buf := make([]byte, size)
+	if p.mlock {
+		lock(buf)
+	}
+	return buf
+}
+
+// wipe zeroes buf. It is kept out of line so that the stores can never be
+// dropped as dead, even when buf is not read afterwards.
+//
+//go:noinline
+func wipe(buf []byte) {
+	clear(buf)
+}
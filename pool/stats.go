@@ -0,0 +1,122 @@
+package pool
+
+import "sync/atomic"
+
+// ClassStats is a snapshot of the counters of one size class.
+type ClassStats struct {
+	// Size is the buffer size served by the class.
+	Size int
+
+	// Gets counts buffers handed out by Get.
+	Gets uint64
+
+	// Puts counts buffers given back through Put.
+	Puts uint64
+
+	// Misses counts gets that found the pool empty and allocated.
+	Misses uint64
+
+	// Drops counts puts that were not kept by the pool.
+	Drops uint64
+
+	// InUseBytes is the capacity of the buffers handed out and not
+	// returned yet.
+	InUseBytes int64
+
+	// RetainedBytes is the capacity of the buffers kept by the pool. It is
+	// an estimate for classes backed by a sync.Pool, see WithBudget.
+	RetainedBytes int64
+}
+
+// Hits returns the number of gets served from the pool.
+func (s ClassStats) Hits() uint64 {
+	if s.Misses > s.Gets {
+		return 0
+	}
+	return s.Gets - s.Misses
+}
+
+// InUse returns the number of buffers handed out and not returned yet.
+func (s ClassStats) InUse() int64 {
+	return int64(s.Gets) - int64(s.Puts)
+}
+
+type classCounters struct {
+	gets   atomic.Uint64
+	puts   atomic.Uint64
+	misses atomic.Uint64
+	drops  atomic.Uint64
+}
+
+func (c *classCounters) snapshot(size int) ClassStats {
+	return ClassStats{
+		Size:   size,
+		Gets:   c.gets.Load(),
+		Puts:   c.puts.Load(),
+		Misses: c.misses.Load(),
+		Drops:  c.drops.Load(),
+	}
+}
+
+func (c *classCounters) reset() {
+	c.gets.Store(0)
+	c.puts.Store(0)
+	c.misses.Store(0)
+	c.drops.Store(0)
+}
+
+// EnableStats switches the per-class counters on or off. Counting is off by
+// default so the fast path stays free of atomic operations.
+func (p *Pool) EnableStats(on bool) {
+	p.statsOn.Store(on)
+}
+
+// Stats returns a snapshot of the counters of every size class, ordered from
+// the smallest class to the largest one.
+func (p *Pool) Stats() []ClassStats {
+	t := p.tab.Load()
+	res := make([]ClassStats, len(t.classes))
+	for i := range t.classes {
+		c := &t.classes[i]
+		res[i] = c.snapshot(c.size)
+		res[i].InUseBytes = res[i].InUse() * int64(c.size)
+		res[i].RetainedBytes = (c.free.Load() + c.victim.Load()) * int64(c.size)
+	}
+	return res
+}
+
+// ResetStats clears the counters of every size class.
+func (p *Pool) ResetStats() {
+	t := p.tab.Load()
+	for i := range t.classes {
+		t.classes[i].reset()
+	}
+	p.large.reset()
+	p.large.inUse.Store(0)
+}
+
+// LargeStats returns a snapshot of the counters of buffers beyond the largest
+// class. Size is always zero.
+func (p *Pool) LargeStats() ClassStats {
+	st := p.large.snapshot(0)
+	st.InUseBytes = p.large.inUse.Load()
+	p.large.mu.Lock()
+	st.RetainedBytes = int64(p.large.retained)
+	p.large.mu.Unlock()
+	return st
+}
+
+// EnableStats switches the per-class counters of Default on or off.
+func EnableStats(on bool) {
+	Default.EnableStats(on)
+}
+
+// Stats returns a snapshot of the counters of Default.
+func Stats() []ClassStats {
+	return Default.Stats()
+}
+
+// ResetStats clears the counters of Default.
+func ResetStats() {
+	Default.ResetStats()
+}
@@ -0,0 +1,31 @@
+package pool
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	EnableStats(true)
+	defer EnableStats(false)
+	ResetStats()
+	defer ResetStats()
+
+	bufs := make([][]byte, 0, 3)
+	for i := 0; i < 3; i++ {
+		bufs = append(bufs, GetBuf(100, false))
+	}
+	PutBuf(bufs[0])
+
+	class := Default.tab.Load().class(100)
+	st := Stats()[class]
+	if st.Size != 128 || st.Gets != 3 || st.Puts != 1 || st.InUse() != 2 {
+		t.Fatalf("expect: size=128, gets=3, puts=1, inuse=2; got: size=%d, gets=%d, puts=%d, inuse=%d", st.Size, st.Gets, st.Puts, st.InUse())
+	}
+	if st.Misses+st.Hits() != st.Gets {
+		t.Fatalf("expect: misses+hits=%d; got: misses=%d, hits=%d", st.Gets, st.Misses, st.Hits())
+	}
+	for _, buf := range bufs[1:] {
+		PutBuf(buf)
+	}
+	if st := Stats()[class]; st.InUse() != 0 {
+		t.Fatalf("expect: inuse=0; got: inuse=%d", st.InUse())
+	}
+}
@@ -0,0 +1,145 @@
+package socket
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/ppltools/utils/pool"
+)
+
+// copyBufferSize is the size of the pooled buffers of the fallback copies.
+const copyBufferSize = 32 << 10
+
+// copyPooled copies src to dst through a buffer of pool.Default. It hides the
+// ReaderFrom and WriterTo shortcuts, which would bypass the buffer.
+func copyPooled(dst io.Writer, src io.Reader) (int64, error) {
+	buf := pool.GetBuf(copyBufferSize, false)
+	defer pool.PutBuf(buf)
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, buf)
+}
+
+// Splice copies src to dst until EOF on src and returns the number of bytes
+// copied. On Linux the bytes move through a pipe with splice(2) without
+// entering user space; elsewhere, or for connections without a socket, it
+// copies through a pooled buffer.
+func Splice(dst, src net.Conn) (int64, error) {
+	return splice(dst, src)
+}
+
+// SendFile sends count bytes of src from offset to dst, or up to the end of
+// src if count is negative, and returns the number of bytes sent. The offset
+// of src is left unchanged. On Linux it uses sendfile(2); elsewhere, or for
+// connections without a socket, it copies through a pooled buffer.
+//
+// If src is not a regular file, e.g. a pipe, offset is ignored: src is read
+// from its current position until EOF or count bytes.
+func SendFile(dst net.Conn, src *os.File, offset, count int64) (int64, error) {
+	fi, err := src.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if !fi.Mode().IsRegular() {
+		var r io.Reader = src
+		if count >= 0 {
+			r = io.LimitReader(src, count)
+		}
+		return copyPooled(dst, r)
+	}
+	if count < 0 {
+		count = max(fi.Size()-offset, 0)
+	}
+	return sendFile(dst, src, offset, count)
+}
+
+// ZeroCopy sets SO_ZEROCOPY, allowing sends with MSG_ZEROCOPY. Linux only;
+// see ZeroCopyConn.
+func ZeroCopy(on bool) Option {
+	return boolOption("SO_ZEROCOPY", soZeroCopy, on)
+}
+
+// MinZeroCopy is the default size below which ZeroCopyConn writes the usual
+// way: pinning pages and handling the completion cost more than copying
+// small writes.
+const MinZeroCopy = 16 << 10
+
+// ZeroCopyStats counts the writes of a ZeroCopyConn.
+type ZeroCopyStats struct {
+	Sends       uint64 // sends with MSG_ZEROCOPY
+	Completions uint64 // of those, sends the kernel notified as complete
+	Copied      uint64 // of those, sends the kernel copied anyway
+	Fallbacks   uint64 // writes sent the usual way
+}
+
+// ZeroCopyConn is a connection sending large writes with MSG_ZEROCOPY: the
+// kernel transmits straight from the buffer of Write instead of copying it,
+// and tells through the error queue of the socket when it is done with it.
+// Write waits for that, so the buffer may be reused once it returns, as
+// io.Writer requires.
+//
+// The kernel copies anyway when it cannot transmit from user memory, e.g.
+// over loopback, which Stats reports as Copied. Where MSG_ZEROCOPY is not
+// supported, writes go the usual way.
+type ZeroCopyConn struct {
+	net.Conn
+	rc      syscall.RawConn
+	enabled bool
+	minSize atomic.Int64
+
+	// mu serializes the zero-copy sends, numbered by the kernel in order.
+	mu        sync.Mutex
+	seq, done uint32
+
+	sends, completions, copied, fallbacks atomic.Uint64
+}
+
+// NewZeroCopyConn sets SO_ZEROCOPY on conn and wraps it to send writes of at
+// least MinZeroCopy bytes with MSG_ZEROCOPY. Where the option is refused it
+// still returns a connection, writing the usual way.
+func NewZeroCopyConn(conn net.Conn) *ZeroCopyConn {
+	c := &ZeroCopyConn{Conn: conn}
+	c.minSize.Store(MinZeroCopy)
+	if Apply(conn, ZeroCopy(true)) == nil {
+		c.rc, _ = rawConn(conn)
+		c.enabled = c.rc != nil
+	}
+	return c
+}
+
+// Enabled reports whether writes may use MSG_ZEROCOPY.
+func (c *ZeroCopyConn) Enabled() bool {
+	return c.enabled
+}
+
+// SetMinSize sets the size below which writes go the usual way.
+func (c *ZeroCopyConn) SetMinSize(n int) {
+	c.minSize.Store(int64(n))
+}
+
+// Write writes b, with MSG_ZEROCOPY if enabled and b is large enough, and
+// returns once the kernel is done with b.
+func (c *ZeroCopyConn) Write(b []byte) (int, error) {
+	if !c.enabled || int64(len(b)) < c.minSize.Load() {
+		c.fallbacks.Add(1)
+		return c.Conn.Write(b)
+	}
+	return c.writeZeroCopy(b)
+}
+
+// Stats returns the counters of c.
+func (c *ZeroCopyConn) Stats() ZeroCopyStats {
+	return ZeroCopyStats{
+		Sends:       c.sends.Load(),
+		Completions: c.completions.Load(),
+		Copied:      c.copied.Load(),
+		Fallbacks:   c.fallbacks.Load(),
+	}
+}
+
+// SyscallConn returns the raw connection, so that Apply works on c.
+func (c *ZeroCopyConn) SyscallConn() (syscall.RawConn, error) {
+	return rawConn(c.Conn)
+}
@@ -0,0 +1,239 @@
+package socket
+
+import (
+	"io"
+	"net"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxSplice is the most moved per splice call, the default pipe capacity.
+const maxSplice = 64 << 10
+
+// maxSendFile is the most sent per sendfile call.
+const maxSendFile = 1 << 30
+
+// fallback reports whether err means the call is not supported for these
+// descriptors, so a copy should be done instead.
+func fallback(err error) bool {
+	return err == unix.EINVAL || err == unix.ENOSYS || err == unix.EOPNOTSUPP
+}
+
+// spliceOnce calls splice, retrying on EINTR.
+func spliceOnce(rfd, wfd, n int) (int64, error) {
+	for {
+		m, err := unix.Splice(rfd, nil, wfd, nil, n, unix.SPLICE_F_MOVE|unix.SPLICE_F_NONBLOCK)
+		if err != unix.EINTR {
+			return int64(m), err
+		}
+	}
+}
+
+func splice(dst, src net.Conn) (int64, error) {
+	srcrc, err := rawConn(src)
+	if err != nil {
+		return copyPooled(dst, src)
+	}
+	dstrc, err := rawConn(dst)
+	if err != nil {
+		return copyPooled(dst, src)
+	}
+	var p [2]int
+	if err := unix.Pipe2(p[:], unix.O_CLOEXEC|unix.O_NONBLOCK); err != nil {
+		return copyPooled(dst, src)
+	}
+	defer unix.Close(p[0])
+	defer unix.Close(p[1])
+
+	var written int64
+	for {
+		// Fill the pipe from src, waiting for data through the runtime
+		// poller.
+		var n int64
+		var serr error
+		err := srcrc.Read(func(fd uintptr) bool {
+			n, serr = spliceOnce(int(fd), p[1], maxSplice)
+			return serr != unix.EAGAIN
+		})
+		if err != nil {
+			return written, err
+		}
+		if serr != nil {
+			if written == 0 && fallback(serr) {
+				return copyPooled(dst, src)
+			}
+			return written, os.NewSyscallError("splice", serr)
+		}
+		if n == 0 {
+			return written, nil
+		}
+
+		// Drain it to dst.
+		for n > 0 {
+			var m int64
+			err := dstrc.Write(func(fd uintptr) bool {
+				m, serr = spliceOnce(p[0], int(fd), int(n))
+				return serr != unix.EAGAIN
+			})
+			if err != nil {
+				return written, err
+			}
+			if serr != nil {
+				return written, os.NewSyscallError("splice", serr)
+			}
+			n -= m
+			written += m
+		}
+	}
+}
+
+func sendFile(dst net.Conn, src *os.File, offset, count int64) (int64, error) {
+	dstrc, err := rawConn(dst)
+	if err != nil {
+		return copyPooled(dst, io.NewSectionReader(src, offset, count))
+	}
+	srcrc, err := src.SyscallConn()
+	if err != nil {
+		return copyPooled(dst, io.NewSectionReader(src, offset, count))
+	}
+	var written int64
+	var serr error
+	err = srcrc.Control(func(sfd uintptr) {
+		off := offset
+		for written < count {
+			var n int
+			err := dstrc.Write(func(fd uintptr) bool {
+				for {
+					n, serr = unix.Sendfile(int(fd), int(sfd), &off, int(min(count-written, maxSendFile)))
+					if serr != unix.EINTR {
+						return serr != unix.EAGAIN
+					}
+				}
+			})
+			if err != nil {
+				serr = err
+				return
+			}
+			if serr != nil || n == 0 {
+				return
+			}
+			written += int64(n)
+		}
+	})
+	if err != nil {
+		return written, err
+	}
+	if serr != nil {
+		if written == 0 && fallback(serr) {
+			return copyPooled(dst, io.NewSectionReader(src, offset, count))
+		}
+		if _, ok := serr.(unix.Errno); ok {
+			serr = os.NewSyscallError("sendfile", serr)
+		}
+		return written, serr
+	}
+	return written, nil
+}
+
+// writeZeroCopy sends b with MSG_ZEROCOPY, then waits for the completion of
+// the sends on the error queue of the socket. Completions raise EPOLLERR,
+// which wakes up the runtime poller for writing too.
+func (c *ZeroCopyConn) writeZeroCopy(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	written := 0
+	var serr error
+	err := c.rc.Write(func(fd uintptr) bool {
+		for written < len(b) {
+			n, err := unix.SendmsgN(int(fd), b[written:], nil, nil, unix.MSG_ZEROCOPY)
+			switch err {
+			case nil:
+				written += n
+				c.seq++
+				c.sends.Add(1)
+				continue
+			case unix.EINTR:
+				continue
+			case unix.ENOBUFS:
+				// Out of memory to pin pages: send the usual way.
+				n, err = unix.Write(int(fd), b[written:])
+				if err == nil {
+					written += n
+					c.fallbacks.Add(1)
+					continue
+				}
+			}
+			if err == unix.EAGAIN {
+				// Free what can be while the socket drains.
+				if serr = c.reap(int(fd)); serr != nil && serr != unix.EAGAIN {
+					return true
+				}
+				serr = nil
+				return false
+			}
+			if err != unix.EINTR {
+				serr = err
+				return true
+			}
+		}
+		for c.done != c.seq {
+			if serr = c.reap(int(fd)); serr == unix.EAGAIN {
+				serr = nil
+				return false
+			} else if serr != nil {
+				return true
+			}
+		}
+		return true
+	})
+	if err == nil && serr != nil {
+		err = os.NewSyscallError("sendmsg", serr)
+	}
+	return written, err
+}
+
+// reap reads one batch of notifications from the error queue of fd. It
+// returns EAGAIN if there is none, and the error of the socket if the queue
+// holds one.
+func (c *ZeroCopyConn) reap(fd int) error {
+	var oob [128]byte
+	var oobn int
+	for {
+		var err error
+		_, oobn, _, _, err = unix.Recvmsg(fd, nil, oob[:], unix.MSG_ERRQUEUE|unix.MSG_DONTWAIT)
+		if err == nil {
+			break
+		}
+		if err != unix.EINTR {
+			return err
+		}
+	}
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		if !(m.Header.Level == unix.SOL_IP && m.Header.Type == unix.IP_RECVERR) &&
+			!(m.Header.Level == unix.SOL_IPV6 && m.Header.Type == unix.IPV6_RECVERR) {
+			continue
+		}
+		if len(m.Data) < int(unsafe.Sizeof(unix.SockExtendedErr{})) {
+			continue
+		}
+		ee := (*unix.SockExtendedErr)(unsafe.Pointer(&m.Data[0]))
+		if ee.Origin != unix.SO_EE_ORIGIN_ZEROCOPY {
+			return unix.Errno(ee.Errno)
+		}
+		// The notification covers the sends numbered Info to Data.
+		n := ee.Data - ee.Info + 1
+		c.done += n
+		c.completions.Add(uint64(n))
+		if ee.Code&unix.SO_EE_CODE_ZEROCOPY_COPIED != 0 {
+			c.copied.Add(uint64(n))
+		}
+	}
+	return nil
+}
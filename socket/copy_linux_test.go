@@ -0,0 +1,99 @@
+package socket
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplice(t *testing.T) {
+	msg := payload(1 << 20)
+	in, src := tcpPair(t)
+	dst, out := tcpPair(t)
+	go func() {
+		in.Write(msg)
+		in.CloseWrite()
+	}()
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(out)
+		got <- b
+	}()
+	n, err := Splice(dst, src)
+	dst.CloseWrite()
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("expect: %d bytes; got: %d, %v", len(msg), n, err)
+	}
+	if !bytes.Equal(<-got, msg) {
+		t.Fatalf("expect: the payload spliced")
+	}
+}
+
+func TestSendFile(t *testing.T) {
+	msg := payload(1 << 20)
+	name := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(name, msg, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	client, server := tcpPair(t)
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(server)
+		got <- b
+	}()
+	n, err := SendFile(client, f, 100, -1)
+	client.CloseWrite()
+	if err != nil || n != int64(len(msg)-100) {
+		t.Fatalf("expect: %d bytes; got: %d, %v", len(msg)-100, n, err)
+	}
+	if !bytes.Equal(<-got, msg[100:]) {
+		t.Fatalf("expect: the file sent from offset 100")
+	}
+	if off, _ := f.Seek(0, io.SeekCurrent); off != 0 {
+		t.Fatalf("expect: file offset left at 0; got: %d", off)
+	}
+}
+
+func TestZeroCopyConn(t *testing.T) {
+	client, server := tcpPair(t)
+	c := NewZeroCopyConn(client)
+	if !c.Enabled() {
+		t.Skipf("SO_ZEROCOPY not supported")
+	}
+	if on, err := GetKeepAlive(c); err != nil || !on {
+		t.Fatalf("expect: options readable through the wrapper; got: %v, %v", on, err)
+	}
+
+	msg := payload(1 << 20)
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(server)
+		got <- b
+	}()
+	for range 4 {
+		if n, err := c.Write(msg); err != nil || n != len(msg) {
+			t.Fatalf("expect: %d bytes written; got: %d, %v", len(msg), n, err)
+		}
+	}
+	if _, err := c.Write([]byte("tail")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	client.CloseWrite()
+
+	b := <-got
+	if len(b) != 4*len(msg)+4 || !bytes.Equal(b[:len(msg)], msg) || string(b[len(b)-4:]) != "tail" {
+		t.Fatalf("expect: %d bytes received; got: %d", 4*len(msg)+4, len(b))
+	}
+	s := c.Stats()
+	if s.Sends == 0 || s.Completions != s.Sends || s.Fallbacks < 1 {
+		t.Fatalf("expect: every zero-copy send completed and one fallback; got: %+v", s)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package socket
+
+import (
+	"io"
+	"net"
+	"os"
+)
+
+func splice(dst, src net.Conn) (int64, error) {
+	return copyPooled(dst, src)
+}
+
+func sendFile(dst net.Conn, src *os.File, offset, count int64) (int64, error) {
+	return copyPooled(dst, io.NewSectionReader(src, offset, count))
+}
+
+func (c *ZeroCopyConn) writeZeroCopy(b []byte) (int, error) {
+	c.fallbacks.Add(1)
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,99 @@
+package socket
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// payload returns n bytes of a recognizable pattern.
+func payload(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i * 7)
+	}
+	return b
+}
+
+func TestSpliceFallback(t *testing.T) {
+	msg := payload(100 << 10)
+	src1, src2 := net.Pipe()
+	dst1, dst2 := net.Pipe()
+	defer dst2.Close()
+	go func() {
+		src1.Write(msg)
+		src1.Close()
+	}()
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(dst2)
+		got <- b
+	}()
+	n, err := Splice(dst1, src2)
+	dst1.Close()
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("expect: %d bytes; got: %d, %v", len(msg), n, err)
+	}
+	if !bytes.Equal(<-got, msg) {
+		t.Fatalf("expect: the payload copied")
+	}
+}
+
+func TestSendFileFallback(t *testing.T) {
+	msg := payload(100 << 10)
+	name := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(name, msg, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	c1, c2 := net.Pipe()
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(c2)
+		got <- b
+	}()
+	n, err := SendFile(c1, f, 10, 1000)
+	c1.Close()
+	if err != nil || n != 1000 {
+		t.Fatalf("expect: 1000 bytes; got: %d, %v", n, err)
+	}
+	if !bytes.Equal(<-got, msg[10:1010]) {
+		t.Fatalf("expect: the section of the file sent")
+	}
+}
+
+func TestSendFilePipe(t *testing.T) {
+	msg := payload(100 << 10)
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	defer pr.Close()
+	go func() {
+		pw.Write(msg)
+		pw.Close()
+	}()
+
+	c1, c2 := net.Pipe()
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(c2)
+		got <- b
+	}()
+	n, err := SendFile(c1, pr, 10, -1)
+	c1.Close()
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("expect: %d bytes; got: %d, %v", len(msg), n, err)
+	}
+	if !bytes.Equal(<-got, msg) {
+		t.Fatalf("expect: the pipe sent until EOF")
+	}
+}
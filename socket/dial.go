@@ -0,0 +1,190 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync/atomic"
+	"syscall"
+)
+
+// BindToDevice sets SO_BINDTODEVICE, only sending and receiving through the
+// named interface. An empty name removes the binding. Linux only.
+func BindToDevice(name string) Option {
+	o := stringOption("SO_BINDTODEVICE", soBindToDevice, name)
+	if len(name) > 15 {
+		return invalid(o, fmt.Errorf("%w: %q", ErrInvalidValue, name))
+	}
+	return o
+}
+
+// GetBindToDevice returns the SO_BINDTODEVICE of conn.
+func GetBindToDevice(conn net.Conn) (string, error) {
+	return getString(conn, "SO_BINDTODEVICE", soBindToDevice)
+}
+
+// Mark sets SO_MARK, the firewall mark used by policy routing. It needs
+// CAP_NET_ADMIN. Linux only.
+func Mark(mark uint32) Option {
+	return intOption("SO_MARK", soMark, int(int32(mark)))
+}
+
+// GetMark returns the SO_MARK of conn.
+func GetMark(conn net.Conn) (uint32, error) {
+	v, err := getInt(conn, "SO_MARK", soMark)
+	return uint32(v), err
+}
+
+// BindAddressNoPort sets IP_BIND_ADDRESS_NO_PORT: binding a source address
+// with port zero leaves the port to connect, which may then share it among
+// different destinations. Linux only.
+func BindAddressNoPort(on bool) Option {
+	return boolOption("IP_BIND_ADDRESS_NO_PORT", ipBindNoPort, on)
+}
+
+// Dialer wraps net.Dialer to apply socket options before connect. Its zero
+// value dials like net.Dial. A Dialer must not be copied once used.
+type Dialer struct {
+	net.Dialer
+
+	// SourceIP is the local address to bind, nil for any. It replaces the
+	// IP of Dialer.LocalAddr, keeping its port and zone.
+	SourceIP net.IP
+
+	// PortMin and PortMax bound the local ports to bind, zero for the port
+	// of Dialer.LocalAddr, or an ephemeral one. Ports are tried in turn
+	// from the one after the last used, until one is free.
+	PortMin, PortMax int
+
+	// Device sets SO_BINDTODEVICE, empty for none.
+	Device string
+
+	// Mark sets SO_MARK, zero for none.
+	Mark uint32
+
+	// BindAddressNoPort sets IP_BIND_ADDRESS_NO_PORT, sparing ephemeral
+	// ports when SourceIP is set without a port range.
+	BindAddressNoPort bool
+
+	// Options are applied after the ones above, before the options given
+	// to each dial.
+	Options Options
+
+	port atomic.Uint32
+}
+
+// options returns all the options to apply before connect.
+func (d *Dialer) options(extra []Option) Options {
+	var opts Options
+	if d.Device != "" {
+		opts = append(opts, BindToDevice(d.Device))
+	}
+	if d.Mark != 0 {
+		opts = append(opts, Mark(d.Mark))
+	}
+	if d.BindAddressNoPort {
+		opts = append(opts, BindAddressNoPort(true))
+	}
+	opts = append(opts, d.Options...)
+	return append(opts, extra...)
+}
+
+// Dial connects to the address on the named network, see DialContext.
+func (d *Dialer) Dial(network, address string, opts ...Option) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address, opts...)
+}
+
+// DialContext connects to the address on the named network, see
+// net.Dialer.DialContext, applying the options of d then opts before
+// connect, and again once connected. Failing options are reported as an
+// *OptionError within the returned error. With a port range, it fails once
+// every port is in use.
+func (d *Dialer) DialContext(ctx context.Context, network, address string, opts ...Option) (net.Conn, error) {
+	ranged := d.PortMin != 0 || d.PortMax != 0
+	if ranged && (d.PortMin < 1 || d.PortMax > math.MaxUint16 || d.PortMin > d.PortMax) {
+		return nil, &OptionError{Option: "source port", Err: fmt.Errorf("%w: [%d, %d]", ErrInvalidValue, d.PortMin, d.PortMax)}
+	}
+	nd := d.Dialer
+	all := d.options(opts)
+	if len(all) > 0 {
+		control := nd.Control
+		nd.Control = func(network, address string, rc syscall.RawConn) error {
+			if control != nil {
+				if err := control(network, address, rc); err != nil {
+					return err
+				}
+			}
+			return all.Control(rc)
+		}
+	}
+	if !ranged {
+		return d.dial(ctx, &nd, network, address, 0, all)
+	}
+
+	n := uint32(d.PortMax - d.PortMin + 1)
+	start := d.port.Add(1)
+	var err error
+	for i := uint32(0); i < n; i++ {
+		port := d.PortMin + int((start+i)%n)
+		var conn net.Conn
+		conn, err = d.dial(ctx, &nd, network, address, port, all)
+		if err == nil {
+			d.port.Store(start + i)
+			return conn, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) && !errors.Is(err, syscall.EADDRNOTAVAIL) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// dial dials through nd bound to SourceIP and port. The net package turns
+// TCP_NODELAY and keepalive on once connected, so opts are applied again.
+func (d *Dialer) dial(ctx context.Context, nd *net.Dialer, network, address string, port int, opts Options) (net.Conn, error) {
+	if d.SourceIP != nil || port != 0 {
+		laddr, err := localAddr(network, d.LocalAddr, d.SourceIP, port)
+		if err != nil {
+			return nil, err
+		}
+		nd.LocalAddr = laddr
+	}
+	conn, err := nd.DialContext(ctx, network, address)
+	if err != nil || len(opts) == 0 {
+		return conn, err
+	}
+	if err := opts.Apply(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// localAddr returns the local address of the given network: base with its
+// IP replaced by ip and its port by port, unless nil or zero.
+func localAddr(network string, base net.Addr, ip net.IP, port int) (net.Addr, error) {
+	var baseIP net.IP
+	var basePort int
+	var zone string
+	switch a := base.(type) {
+	case *net.TCPAddr:
+		baseIP, basePort, zone = a.IP, a.Port, a.Zone
+	case *net.UDPAddr:
+		baseIP, basePort, zone = a.IP, a.Port, a.Zone
+	}
+	if ip == nil {
+		ip = baseIP
+	}
+	if port == 0 {
+		port = basePort
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return &net.TCPAddr{IP: ip, Port: port, Zone: zone}, nil
+	case "udp", "udp4", "udp6":
+		return &net.UDPAddr{IP: ip, Port: port, Zone: zone}, nil
+	}
+	return nil, &net.OpError{Op: "dial", Net: network, Err: net.UnknownNetworkError(network)}
+}
@@ -0,0 +1,158 @@
+package socket
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// loopbackServer accepts connections on loopback until the test ends and
+// returns its address and the accepted connections.
+func loopbackServer(t *testing.T) (string, <-chan net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	accepted := make(chan net.Conn, 16)
+	go func() {
+		defer close(accepted)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		for conn := range accepted {
+			conn.Close()
+		}
+	})
+	return ln.Addr().String(), accepted
+}
+
+// freePort returns a port free on loopback at the time of the call.
+func freePort(t *testing.T) int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestDialSourcePort(t *testing.T) {
+	addr, accepted := loopbackServer(t)
+	port := freePort(t)
+	d := &Dialer{SourceIP: net.IPv4(127, 0, 0, 1), PortMin: port, PortMax: port}
+
+	conn, err := d.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	peer := <-accepted
+	if got := peer.RemoteAddr().(*net.TCPAddr).Port; got != port {
+		t.Fatalf("expect: source port %d; got: %d", port, got)
+	}
+
+	// The only port of the range is taken.
+	if conn, err := d.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Fatalf("expect: dial failing with the range exhausted")
+	} else if !errors.Is(err, unix.EADDRINUSE) && !errors.Is(err, unix.EADDRNOTAVAIL) {
+		t.Fatalf("expect: %v; got: %v", unix.EADDRINUSE, err)
+	}
+}
+
+func TestDialLocalAddr(t *testing.T) {
+	addr, accepted := loopbackServer(t)
+	port := freePort(t)
+	d := &Dialer{
+		Dialer:   net.Dialer{LocalAddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: port}},
+		SourceIP: net.IPv4(127, 0, 0, 1),
+	}
+	conn, err := d.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	src := (<-accepted).RemoteAddr().(*net.TCPAddr)
+	if !src.IP.Equal(d.SourceIP) || src.Port != port {
+		t.Fatalf("expect: source 127.0.0.1:%d; got: %v", port, src)
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	addr, _ := loopbackServer(t)
+	d := &Dialer{
+		SourceIP:          net.IPv4(127, 0, 0, 1),
+		BindAddressNoPort: true,
+		Options:           Options{NoDelay(false)},
+	}
+	conn, err := d.Dial("tcp", addr, KeepAliveCount(4))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	tc := conn.(*net.TCPConn)
+	if v := getsockopt(t, tc, unix.IPPROTO_IP, unix.IP_BIND_ADDRESS_NO_PORT); v != 1 {
+		t.Fatalf("expect: bind_address_no_port=1; got: %d", v)
+	}
+	if on, err := GetNoDelay(conn); err != nil || on {
+		t.Fatalf("expect: nodelay off; got: %v, %v", on, err)
+	}
+	if n, err := GetKeepAliveCount(conn); err != nil || n != 4 {
+		t.Fatalf("expect: keepcnt=4; got: %d, %v", n, err)
+	}
+
+	// Per-dial options do not stick to the Dialer.
+	conn2, err := d.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn2.Close()
+	if n, err := GetKeepAliveCount(conn2); err != nil || n == 4 {
+		t.Fatalf("expect: default keepcnt; got: %d, %v", n, err)
+	}
+}
+
+func TestDialPrivileged(t *testing.T) {
+	addr, _ := loopbackServer(t)
+	d := &Dialer{Device: "lo", Mark: 42}
+	conn, err := d.Dial("tcp", addr)
+	if errors.Is(err, unix.EPERM) {
+		t.Skipf("dial: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if mark, err := GetMark(conn); err != nil || mark != 42 {
+		t.Fatalf("expect: mark=42; got: %d, %v", mark, err)
+	}
+	if dev, err := GetBindToDevice(conn); err != nil || dev != "lo" {
+		t.Fatalf("expect: device lo; got: %q, %v", dev, err)
+	}
+}
+
+func TestDialInvalid(t *testing.T) {
+	for _, d := range []*Dialer{
+		{PortMin: 2000, PortMax: 1000},
+		{PortMax: 1000},
+		{PortMin: 1000, PortMax: 70000},
+		{Device: "a-very-long-device-name"},
+	} {
+		conn, err := d.Dial("tcp", "127.0.0.1:1")
+		if err == nil {
+			conn.Close()
+		}
+		if !errors.Is(err, ErrInvalidValue) {
+			t.Fatalf("case: %d-%d mark=%d device=%q  expect: %v; got: %v", d.PortMin, d.PortMax, d.Mark, d.Device, ErrInvalidValue, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package socket
+
+import (
+	"net"
+	"syscall"
+)
+
+// rawConn returns the raw connection behind c, or ErrUnsupported if c does
+// not expose one.
+func rawConn(c any) (syscall.RawConn, error) {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return sc.SyscallConn()
+}
+
+// control calls fn with the socket of conn. It goes through
+// syscall.RawConn, so the descriptor is neither duplicated nor switched out
+// of non-blocking mode.
+func control(conn net.Conn, fn func(fd uintptr) error) error {
+	rc, err := rawConn(conn)
+	if err != nil {
+		return err
+	}
+	return rawControl(rc, fn)
+}
+
+// rawControl calls fn with the socket behind rc.
+func rawControl(rc syscall.RawConn, fn func(fd uintptr) error) error {
+	var ferr error
+	if err := rc.Control(func(fd uintptr) {
+		ferr = fn(fd)
+	}); err != nil {
+		return err
+	}
+	return ferr
+}
@@ -0,0 +1,38 @@
+package socket
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func countFDs(t *testing.T) int {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skipf("read /proc/self/fd: %v", err)
+	}
+	return len(fds)
+}
+
+func TestControlNoDup(t *testing.T) {
+	client, _ := tcpPair(t)
+	before := countFDs(t)
+	for i := 0; i < 100; i++ {
+		if err := SetQuickAck(client); err != nil {
+			t.Fatalf("SetQuickAck: %v", err)
+		}
+	}
+	if after := countFDs(t); after != before {
+		t.Fatalf("expect: %d fds; got: %d", before, after)
+	}
+
+	var flags int
+	control(client, func(fd uintptr) error {
+		flags, _ = unix.FcntlInt(fd, unix.F_GETFL, 0)
+		return nil
+	})
+	if flags&unix.O_NONBLOCK == 0 {
+		t.Fatalf("expect: socket still non-blocking")
+	}
+}
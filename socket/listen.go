@@ -0,0 +1,133 @@
+package socket
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ReuseAddr sets SO_REUSEADDR, allowing to bind an address in TIME_WAIT. Go
+// already sets it on listeners on Unix.
+func ReuseAddr(on bool) Option {
+	return boolOption("SO_REUSEADDR", soReuseAddr, on)
+}
+
+// ReusePort sets SO_REUSEPORT, allowing several sockets to bind the same
+// address. The kernel balances incoming connections between listeners.
+func ReusePort(on bool) Option {
+	return boolOption("SO_REUSEPORT", soReusePort, on)
+}
+
+// FastOpen sets TCP_FASTOPEN on a listener, the length of the queue of
+// connections whose handshake carries data. Zero disables it.
+func FastOpen(qlen int) Option {
+	o := intOption("TCP_FASTOPEN", tcpFastOpen, qlen)
+	if qlen < 0 || qlen > math.MaxInt32 {
+		return invalid(o, fmt.Errorf("%w: %d", ErrInvalidValue, qlen))
+	}
+	return o
+}
+
+// DeferAccept sets TCP_DEFER_ACCEPT on a listener: accept waits for data to
+// arrive, for up to d rounded up to the second. The kernel rounds it further
+// to a number of SYN-ACK retransmissions. Zero disables it. Linux only.
+func DeferAccept(d time.Duration) Option {
+	if d == 0 {
+		return intOption("TCP_DEFER_ACCEPT", tcpDeferAccept, 0)
+	}
+	s, err := seconds(d)
+	return invalid(intOption("TCP_DEFER_ACCEPT", tcpDeferAccept, s), err)
+}
+
+// ListenConfig builds listeners whose options are set before bind. The zero
+// value listens like net.Listen.
+type ListenConfig struct {
+	// ReuseAddr and ReusePort set SO_REUSEADDR and SO_REUSEPORT.
+	ReuseAddr bool
+	ReusePort bool
+
+	// Backlog is the length of the accept queue, capped by the kernel at
+	// net.core.somaxconn. Zero keeps the default of the net package.
+	Backlog int
+
+	// FastOpen is the TCP_FASTOPEN queue length, zero leaves it off.
+	FastOpen int
+
+	// DeferAccept sets TCP_DEFER_ACCEPT, zero leaves it off.
+	DeferAccept time.Duration
+
+	// KeepAlive is passed to net.ListenConfig for accepted connections.
+	KeepAlive time.Duration
+
+	// Options are applied to the listening socket after the ones above.
+	// On Linux accepted connections inherit most TCP options.
+	Options Options
+}
+
+// options returns all the options to apply before bind.
+func (lc *ListenConfig) options() Options {
+	var opts Options
+	if lc.ReuseAddr {
+		opts = append(opts, ReuseAddr(true))
+	}
+	if lc.ReusePort {
+		opts = append(opts, ReusePort(true))
+	}
+	if lc.FastOpen != 0 {
+		opts = append(opts, FastOpen(lc.FastOpen))
+	}
+	if lc.DeferAccept != 0 {
+		opts = append(opts, DeferAccept(lc.DeferAccept))
+	}
+	return append(opts, lc.Options...)
+}
+
+// Listen announces on the local network address, see net.Listen. Failing
+// options are reported as an *OptionError within the returned error.
+func (lc *ListenConfig) Listen(ctx context.Context, network, address string) (net.Listener, error) {
+	if lc.Backlog < 0 || lc.Backlog > math.MaxInt32 {
+		return nil, &OptionError{Option: "backlog", Err: fmt.Errorf("%w: %d", ErrInvalidValue, lc.Backlog)}
+	}
+	opts := lc.options()
+	nlc := net.ListenConfig{KeepAlive: lc.KeepAlive}
+	if len(opts) > 0 {
+		nlc.Control = func(_, _ string, rc syscall.RawConn) error {
+			return opts.Control(rc)
+		}
+	}
+	ln, err := nlc.Listen(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	if lc.Backlog > 0 {
+		if err := setBacklog(ln, lc.Backlog); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// setBacklog calls listen again on the socket of ln, which only updates the
+// length of its accept queue.
+func setBacklog(ln net.Listener, backlog int) error {
+	rc, err := rawConn(ln)
+	if err == nil {
+		err = rawControl(rc, func(fd uintptr) error {
+			return listen(fd, backlog)
+		})
+	}
+	if err != nil {
+		return &OptionError{Option: "backlog", Err: err}
+	}
+	return nil
+}
+
+// Listen announces on the local network address with the options of lc, see
+// ListenConfig.Listen.
+func Listen(network, address string, lc ListenConfig) (net.Listener, error) {
+	return lc.Listen(context.Background(), network, address)
+}
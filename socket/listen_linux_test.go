@@ -0,0 +1,167 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// listenerOpt returns an integer option of the socket of ln.
+func listenerOpt(t *testing.T, ln net.Listener, level, opt int) int {
+	rc, err := ln.(syscall.Conn).SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn: %v", err)
+	}
+	var v int
+	var serr error
+	rc.Control(func(fd uintptr) {
+		v, serr = unix.GetsockoptInt(int(fd), level, opt)
+	})
+	if serr != nil {
+		t.Fatalf("getsockopt: %v", serr)
+	}
+	return v
+}
+
+func TestListenReusePort(t *testing.T) {
+	lc := ListenConfig{ReusePort: true, Backlog: 16}
+	first, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	lns := []net.Listener{first}
+	for range 3 {
+		ln, err := lc.Listen(context.Background(), "tcp", first.Addr().String())
+		if err != nil {
+			t.Fatalf("Listen on a shared port: %v", err)
+		}
+		lns = append(lns, ln)
+	}
+	defer func() {
+		for _, ln := range lns {
+			ln.Close()
+		}
+	}()
+
+	// A listener without SO_REUSEPORT cannot join.
+	if ln, err := net.Listen("tcp", first.Addr().String()); err == nil {
+		ln.Close()
+		t.Fatalf("expect: EADDRINUSE without SO_REUSEPORT")
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	accepted := make([]int, len(lns))
+	for i, ln := range lns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+				mu.Lock()
+				accepted[i]++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	const conns = 64
+	for range conns {
+		conn, err := net.Dial("tcp", first.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		conn.Close()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		total, used := 0, 0
+		for _, n := range accepted {
+			total += n
+			if n > 0 {
+				used++
+			}
+		}
+		mu.Unlock()
+		if total == conns {
+			if used < 2 {
+				t.Fatalf("expect: connections spread over listeners; got: %v", accepted)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect: %d connections accepted; got: %v", conns, accepted)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, ln := range lns {
+		ln.Close()
+	}
+	wg.Wait()
+}
+
+func TestListenOptions(t *testing.T) {
+	lc := ListenConfig{
+		ReuseAddr:   true,
+		Backlog:     7,
+		FastOpen:    32,
+		DeferAccept: 5 * time.Second,
+		Options:     Options{NoDelay(true)},
+	}
+	ln, err := Listen("tcp", "127.0.0.1:0", lc)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+
+	if v := listenerOpt(t, ln, unix.SOL_SOCKET, unix.SO_REUSEADDR); v != 1 {
+		t.Fatalf("expect: reuseaddr=1; got: %d", v)
+	}
+	if v := listenerOpt(t, ln, unix.IPPROTO_TCP, unix.TCP_FASTOPEN); v != 32 {
+		t.Fatalf("expect: fastopen=32; got: %d", v)
+	}
+	if v := listenerOpt(t, ln, unix.IPPROTO_TCP, unix.TCP_DEFER_ACCEPT); v < 5 {
+		t.Fatalf("expect: defer_accept>=5; got: %d", v)
+	}
+	if v := listenerOpt(t, ln, unix.IPPROTO_TCP, unix.TCP_NODELAY); v != 1 {
+		t.Fatalf("expect: nodelay=1; got: %d", v)
+	}
+
+	// On a listener tcpi_sacked holds the accept queue length.
+	rc, _ := ln.(syscall.Conn).SyscallConn()
+	var info *unix.TCPInfo
+	rc.Control(func(fd uintptr) {
+		info, err = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil || info.Sacked != 7 {
+		t.Fatalf("expect: backlog=7; got: %v, %v", info, err)
+	}
+}
+
+func TestListenInvalid(t *testing.T) {
+	for _, lc := range []ListenConfig{
+		{Backlog: -1},
+		{FastOpen: -1},
+		{DeferAccept: -time.Second},
+		{Options: Options{KeepAliveCount(0)}},
+	} {
+		ln, err := Listen("tcp", "127.0.0.1:0", lc)
+		if err == nil {
+			ln.Close()
+		}
+		if !errors.Is(err, ErrInvalidValue) {
+			t.Fatalf("case: %+v  expect: %v; got: %v", lc, ErrInvalidValue, err)
+		}
+	}
+}
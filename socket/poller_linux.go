@@ -0,0 +1,416 @@
+package socket
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ppltools/utils/pool"
+	"golang.org/x/sys/unix"
+)
+
+// ErrPollerClosed is returned by a Poller once closed.
+var ErrPollerClosed = errors.New("socket: poller closed")
+
+// ErrWouldBlock is returned by Poller.Read and Poller.Write when the socket is
+// not ready. In edge-triggered mode, wait for the next event.
+var ErrWouldBlock = errors.New("socket: operation would block")
+
+// ErrNotRegistered is returned for connections unknown to the Poller.
+var ErrNotRegistered = errors.New("socket: connection not registered")
+
+// Event is a set of readiness events of a connection.
+type Event uint32
+
+const (
+	Readable Event = 1 << iota
+	Writable
+	Hangup // the peer closed its side or the connection failed
+)
+
+func (e Event) String() string {
+	var s []string
+	for _, n := range []struct {
+		ev   Event
+		name string
+	}{{Readable, "readable"}, {Writable, "writable"}, {Hangup, "hangup"}} {
+		if e&n.ev != 0 {
+			s = append(s, n.name)
+		}
+	}
+	return strings.Join(s, "|")
+}
+
+// Trigger selects how readiness is reported.
+type Trigger int
+
+const (
+	// Level reports an event on every poll as long as the condition holds.
+	Level Trigger = iota
+
+	// Edge reports an event once when the condition arises: the handler
+	// must then read or write until ErrWouldBlock.
+	Edge
+)
+
+// PollEvent is the event of a connection delivered on Poller.Events.
+type PollEvent struct {
+	Conn   net.Conn
+	Events Event
+}
+
+// PollHandler handles the events of a connection. It runs on the goroutine
+// polling, so it must not block.
+type PollHandler func(conn net.Conn, ev Event)
+
+// PollerConfig configures a Poller created by NewPoller.
+type PollerConfig struct {
+	// Buffers serves the buffers of Poller.Read, nil for pool.Default.
+	Buffers *pool.Pool
+
+	// Backlog is the capacity of the Events channel, 1024 if zero.
+	Backlog int
+
+	// MaxEvents is the number of events fetched per poll, 256 if zero.
+	MaxEvents int
+}
+
+type registration struct {
+	conn    net.Conn
+	handler PollHandler
+	trigger Trigger
+}
+
+// Poller watches many connections from a single goroutine with epoll, instead
+// of a goroutine blocked in Read per connection. Connections are registered
+// by their descriptor: remove them before closing them, as the descriptor
+// may otherwise be reused for an unrelated socket.
+//
+// Connections stay registered with the runtime poller too, so their Read and
+// Write methods keep working; the non-blocking Read and Write of the Poller
+// suit its event loop better.
+type Poller struct {
+	epfd    int
+	wakefd  int
+	buffers *pool.Pool
+	events  chan PollEvent
+	raw     []unix.EpollEvent
+
+	// mu guards conns and the closing of the descriptors, which waits for
+	// the goroutine polling if any.
+	mu      sync.RWMutex
+	conns   map[int32]*registration
+	polling bool
+	closed  atomic.Bool
+	done    chan struct{}
+
+	pollMu sync.Mutex
+}
+
+// NewPoller creates a Poller.
+func NewPoller(cfg PollerConfig) (*Poller, error) {
+	if cfg.Buffers == nil {
+		cfg.Buffers = pool.Default
+	}
+	if cfg.Backlog == 0 {
+		cfg.Backlog = 1024
+	}
+	if cfg.MaxEvents == 0 {
+		cfg.MaxEvents = 256
+	}
+	epfd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	wakefd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		unix.Close(epfd)
+		return nil, err
+	}
+	ev := unix.EpollEvent{Events: unix.EPOLLIN, Fd: int32(wakefd)}
+	if err := unix.EpollCtl(epfd, unix.EPOLL_CTL_ADD, wakefd, &ev); err != nil {
+		unix.Close(epfd)
+		unix.Close(wakefd)
+		return nil, err
+	}
+	return &Poller{
+		epfd:    epfd,
+		wakefd:  wakefd,
+		buffers: cfg.Buffers,
+		events:  make(chan PollEvent, cfg.Backlog),
+		raw:     make([]unix.EpollEvent, cfg.MaxEvents),
+		conns:   map[int32]*registration{},
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// fd returns the descriptor of conn.
+func fd(conn net.Conn) (int32, error) {
+	var sfd int32
+	err := control(conn, func(fd uintptr) error {
+		sfd = int32(fd)
+		return nil
+	})
+	return sfd, err
+}
+
+func epollEvents(events Event, trigger Trigger) uint32 {
+	ev := uint32(unix.EPOLLRDHUP)
+	if events&Readable != 0 {
+		ev |= unix.EPOLLIN
+	}
+	if events&Writable != 0 {
+		ev |= unix.EPOLLOUT
+	}
+	if trigger == Edge {
+		ev |= unix.EPOLLET
+	}
+	return ev
+}
+
+// Add registers conn for events, which Hangup is always part of. The events
+// are passed to h, or sent on the Events channel if h is nil.
+func (p *Poller) Add(conn net.Conn, events Event, trigger Trigger, h PollHandler) error {
+	sfd, err := fd(conn)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed.Load() {
+		return ErrPollerClosed
+	}
+	ev := unix.EpollEvent{Events: epollEvents(events, trigger), Fd: sfd}
+	if err := unix.EpollCtl(p.epfd, unix.EPOLL_CTL_ADD, int(sfd), &ev); err != nil {
+		return err
+	}
+	p.conns[sfd] = &registration{conn: conn, handler: h, trigger: trigger}
+	return nil
+}
+
+// Modify changes the events conn is registered for.
+func (p *Poller) Modify(conn net.Conn, events Event) error {
+	sfd, err := fd(conn)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed.Load() {
+		return ErrPollerClosed
+	}
+	r, ok := p.conns[sfd]
+	if !ok {
+		return ErrNotRegistered
+	}
+	ev := unix.EpollEvent{Events: epollEvents(events, r.trigger), Fd: sfd}
+	return unix.EpollCtl(p.epfd, unix.EPOLL_CTL_MOD, int(sfd), &ev)
+}
+
+// Remove unregisters conn. Events already fetched may still be delivered.
+func (p *Poller) Remove(conn net.Conn) error {
+	sfd, err := fd(conn)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed.Load() {
+		return ErrPollerClosed
+	}
+	if _, ok := p.conns[sfd]; !ok {
+		return ErrNotRegistered
+	}
+	delete(p.conns, sfd)
+	return unix.EpollCtl(p.epfd, unix.EPOLL_CTL_DEL, int(sfd), nil)
+}
+
+// Len returns the number of registered connections.
+func (p *Poller) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.conns)
+}
+
+// Events returns the channel receiving the events of the connections added
+// without a handler. Polling blocks while it is full, until Close. The
+// channel is closed once the poller is closed and polling has stopped.
+func (p *Poller) Events() <-chan PollEvent {
+	return p.events
+}
+
+// Poll waits up to timeout for events and dispatches them, returning how
+// many. A negative timeout waits until an event or Close. Only one goroutine
+// polls at a time. Once the poller is closed, the events left are dropped
+// and Poll returns ErrPollerClosed.
+func (p *Poller) Poll(timeout time.Duration) (int, error) {
+	p.pollMu.Lock()
+	defer p.pollMu.Unlock()
+	p.mu.Lock()
+	if p.closed.Load() {
+		p.mu.Unlock()
+		return 0, ErrPollerClosed
+	}
+	p.polling = true
+	p.mu.Unlock()
+	defer p.stopPolling()
+
+	ms := -1
+	if timeout >= 0 {
+		ms = int((timeout + time.Millisecond - 1) / time.Millisecond)
+	}
+	n, err := unix.EpollWait(p.epfd, p.raw, ms)
+	if err == unix.EINTR {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, raw := range p.raw[:n] {
+		if p.closed.Load() {
+			break
+		}
+		if raw.Fd == int32(p.wakefd) {
+			continue
+		}
+		p.mu.RLock()
+		r, ok := p.conns[raw.Fd]
+		p.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		var ev Event
+		if raw.Events&unix.EPOLLIN != 0 {
+			ev |= Readable
+		}
+		if raw.Events&unix.EPOLLOUT != 0 {
+			ev |= Writable
+		}
+		if raw.Events&(unix.EPOLLHUP|unix.EPOLLRDHUP|unix.EPOLLERR) != 0 {
+			ev |= Hangup
+		}
+		if r.handler != nil {
+			r.handler(r.conn, ev)
+		} else {
+			select {
+			case p.events <- PollEvent{Conn: r.conn, Events: ev}:
+			case <-p.done:
+				return dispatched, ErrPollerClosed
+			}
+		}
+		dispatched++
+	}
+	if p.closed.Load() {
+		return dispatched, ErrPollerClosed
+	}
+	return dispatched, nil
+}
+
+// stopPolling ends a Poll, releasing the poller if it was closed meanwhile.
+func (p *Poller) stopPolling() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.polling = false
+	if p.closed.Load() {
+		p.release()
+	}
+}
+
+// Run polls until Close, then returns nil.
+func (p *Poller) Run() error {
+	for {
+		if _, err := p.Poll(-1); err != nil {
+			if err == ErrPollerClosed {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Read reads what is available on conn without blocking, into a buffer of
+// the pool of p able to hold size bytes. The caller gives the buffer back
+// with Put on that pool. It returns ErrWouldBlock if there is nothing to
+// read, and io.EOF once the peer closed its side.
+func (p *Poller) Read(conn net.Conn, size int) ([]byte, error) {
+	buf := p.buffers.Get(size, false)
+	var n int
+	err := control(conn, func(fd uintptr) error {
+		var err error
+		for {
+			n, err = unix.Read(int(fd), buf)
+			if err != unix.EINTR {
+				return err
+			}
+		}
+	})
+	switch {
+	case err == unix.EAGAIN:
+		err = ErrWouldBlock
+	case err == nil && n == 0:
+		err = io.EOF
+	}
+	if err != nil {
+		p.buffers.Put(buf)
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// Write writes what fits of b on conn without blocking. It returns
+// ErrWouldBlock if nothing fits; register for Writable to learn when to try
+// again.
+func (p *Poller) Write(conn net.Conn, b []byte) (int, error) {
+	var n int
+	err := control(conn, func(fd uintptr) error {
+		var err error
+		for {
+			n, err = unix.Write(int(fd), b)
+			if err != unix.EINTR {
+				return err
+			}
+		}
+	})
+	if err == unix.EAGAIN {
+		return 0, ErrWouldBlock
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close wakes up the goroutine polling and releases the poller, as soon as
+// that goroutine returns if any. It may be called from a handler. The
+// registered connections are left open.
+func (p *Poller) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed.Swap(true) {
+		return ErrPollerClosed
+	}
+	close(p.done)
+	if p.polling {
+		var one [8]byte
+		one[0] = 1
+		unix.Write(p.wakefd, one[:])
+		return nil
+	}
+	p.release()
+	return nil
+}
+
+// release closes the descriptors and the Events channel once closed and no
+// longer polling. p.mu must be held.
+func (p *Poller) release() {
+	p.conns = map[int32]*registration{}
+	unix.Close(p.wakefd)
+	unix.Close(p.epfd)
+	close(p.events)
+}
@@ -0,0 +1,277 @@
+package socket
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ppltools/utils/pool"
+)
+
+func newPoller(t *testing.T) *Poller {
+	p, err := NewPoller(PollerConfig{})
+	if err != nil {
+		t.Fatalf("NewPoller: %v", err)
+	}
+	t.Cleanup(func() {
+		p.Close()
+	})
+	return p
+}
+
+// poll polls p once and fails unless want events come in.
+func poll(t *testing.T, p *Poller, want int) {
+	t.Helper()
+	n, err := p.Poll(100 * time.Millisecond)
+	if err != nil || n != want {
+		t.Fatalf("expect: %d events; got: %d, %v", want, n, err)
+	}
+}
+
+func TestPollerLevel(t *testing.T) {
+	p := newPoller(t)
+	client, server := tcpPair(t)
+	var got []Event
+	err := p.Add(server, Readable, Level, func(conn net.Conn, ev Event) {
+		if conn != server {
+			t.Fatalf("expect: the server conn")
+		}
+		got = append(got, ev)
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if n, err := p.Poll(0); err != nil || n != 0 {
+		t.Fatalf("expect: no event; got: %d, %v", n, err)
+	}
+	client.Write([]byte("hello"))
+	poll(t, p, 1)
+	// Level-triggered: reported again until read.
+	poll(t, p, 1)
+	if len(got) != 2 || got[0] != Readable || got[1] != Readable {
+		t.Fatalf("expect: two readable events; got: %v", got)
+	}
+
+	buf, err := p.Read(server, 64)
+	if err != nil || string(buf) != "hello" {
+		t.Fatalf("expect: hello; got: %q, %v", buf, err)
+	}
+	pool.PutBuf(buf)
+	if _, err := p.Read(server, 64); !errors.Is(err, ErrWouldBlock) {
+		t.Fatalf("expect: %v; got: %v", ErrWouldBlock, err)
+	}
+	poll(t, p, 0)
+
+	client.Close()
+	poll(t, p, 1)
+	if ev := got[len(got)-1]; ev&Hangup == 0 {
+		t.Fatalf("expect: hangup; got: %v", ev)
+	}
+	if _, err := p.Read(server, 64); err != io.EOF {
+		t.Fatalf("expect: %v; got: %v", io.EOF, err)
+	}
+}
+
+func TestPollerEdge(t *testing.T) {
+	p := newPoller(t)
+	client, server := tcpPair(t)
+	if err := p.Add(server, Readable|Writable, Edge, nil); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	poll(t, p, 1)
+	if ev := <-p.Events(); ev.Conn != server || ev.Events != Writable {
+		t.Fatalf("expect: writable; got: %v", ev.Events)
+	}
+	// Edge-triggered: not reported again.
+	poll(t, p, 0)
+
+	client.Write([]byte("a"))
+	poll(t, p, 1)
+	if ev := <-p.Events(); ev.Events&Readable == 0 {
+		t.Fatalf("expect: readable; got: %v", ev.Events)
+	}
+	poll(t, p, 0)
+
+	if err := p.Modify(server, Readable); err != nil {
+		t.Fatalf("Modify: %v", err)
+	}
+	if err := p.Remove(server); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := p.Remove(server); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expect: %v; got: %v", ErrNotRegistered, err)
+	}
+	client.Write([]byte("b"))
+	poll(t, p, 0)
+	if p.Len() != 0 {
+		t.Fatalf("expect: no connection registered; got: %d", p.Len())
+	}
+}
+
+func TestPollerWrite(t *testing.T) {
+	p := newPoller(t)
+	client, server := tcpPair(t)
+	if err := Apply(server, SendBuffer(4096)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	b := make([]byte, 64<<10)
+	total := 0
+	for {
+		n, err := p.Write(server, b)
+		total += n
+		if errors.Is(err, ErrWouldBlock) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := p.Add(server, Writable, Level, nil); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	go io.CopyN(io.Discard, client, int64(total))
+	if _, err := p.Poll(5 * time.Second); err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if ev := <-p.Events(); ev.Events&Writable == 0 {
+		t.Fatalf("expect: writable; got: %v", ev.Events)
+	}
+}
+
+func TestPollerRun(t *testing.T) {
+	p := newPoller(t)
+	const conns = 32
+	var wg sync.WaitGroup
+	wg.Add(conns)
+	clients := make([]net.Conn, conns)
+	for i := range clients {
+		client, server := tcpPair(t)
+		clients[i] = client
+		err := p.Add(server, Readable, Edge, func(conn net.Conn, ev Event) {
+			// Echo until drained, as edge-triggered requires.
+			for {
+				buf, err := p.Read(conn, 512)
+				if err != nil {
+					if err != ErrWouldBlock {
+						p.Remove(conn)
+					}
+					return
+				}
+				p.Write(conn, buf)
+				pool.PutBuf(buf)
+			}
+		})
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- p.Run()
+	}()
+	for _, client := range clients {
+		go func() {
+			defer wg.Done()
+			msg := []byte("ping")
+			client.Write(msg)
+			if _, err := io.ReadFull(client, msg); err != nil || string(msg) != "ping" {
+				t.Errorf("expect: ping; got: %q, %v", msg, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	p.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expect: Run to return on Close")
+	}
+	if _, err := p.Poll(0); !errors.Is(err, ErrPollerClosed) {
+		t.Fatalf("expect: %v; got: %v", ErrPollerClosed, err)
+	}
+}
+
+func TestPollerCloseBlocked(t *testing.T) {
+	p, err := NewPoller(PollerConfig{Backlog: 1})
+	if err != nil {
+		t.Fatalf("NewPoller: %v", err)
+	}
+	for range 2 {
+		client, server := tcpPair(t)
+		if err := p.Add(server, Readable, Level, nil); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		client.Write([]byte("hello"))
+	}
+
+	// The second event blocks Poll on the full Events channel.
+	done := make(chan error)
+	go func() {
+		_, err := p.Poll(-1)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	closed := make(chan error)
+	go func() {
+		closed <- p.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expect: Close to return with Events full")
+	}
+	if err := <-done; !errors.Is(err, ErrPollerClosed) {
+		t.Fatalf("expect: %v; got: %v", ErrPollerClosed, err)
+	}
+	n := 0
+	for range p.Events() {
+		n++
+	}
+	if n != 1 {
+		t.Fatalf("expect: Events closed after one event; got: %d", n)
+	}
+	_, server := tcpPair(t)
+	if err := p.Add(server, Readable, Level, nil); !errors.Is(err, ErrPollerClosed) {
+		t.Fatalf("expect: %v; got: %v", ErrPollerClosed, err)
+	}
+}
+
+func TestPollerCloseFromHandler(t *testing.T) {
+	p := newPoller(t)
+	client, server := tcpPair(t)
+	err := p.Add(server, Readable, Level, func(net.Conn, Event) {
+		p.Close()
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	client.Write([]byte("hello"))
+
+	done := make(chan error)
+	go func() {
+		done <- p.Run()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expect: Run to return on Close from a handler")
+	}
+	if _, ok := <-p.Events(); ok {
+		t.Fatalf("expect: Events closed")
+	}
+}
@@ -0,0 +1,59 @@
+package socket
+
+import (
+	"net"
+	"sync/atomic"
+	"syscall"
+)
+
+// QuickAckConn is a connection keeping TCP_QUICKACK armed. Linux does not
+// keep the option: it falls back to delayed ACKs after a while, so a one-shot
+// QuickAck barely helps request/response traffic. QuickAckConn sets it again
+// after every Read returning data.
+//
+// Only Read is re-arming, so the connection deliberately hides the
+// ReadFrom/WriteTo shortcuts of *net.TCPConn that would bypass it.
+type QuickAckConn struct {
+	net.Conn
+	rc    syscall.RawConn
+	set   setter
+	rearm atomic.Bool
+}
+
+// NewQuickAckConn sets TCP_QUICKACK on conn and wraps it to re-arm the
+// option after every Read. It fails with ErrUnsupported where the platform
+// has no TCP_QUICKACK.
+func NewQuickAckConn(conn net.Conn) (*QuickAckConn, error) {
+	o := QuickAck(true)
+	if err := Apply(conn, o); err != nil {
+		return nil, err
+	}
+	rc, err := conn.(syscall.Conn).SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	c := &QuickAckConn{Conn: conn, rc: rc, set: o.set}
+	c.rearm.Store(true)
+	return c, nil
+}
+
+// SetRearm switches re-arming after Read on or off.
+func (c *QuickAckConn) SetRearm(on bool) {
+	c.rearm.Store(on)
+}
+
+// Read reads from the connection and re-arms TCP_QUICKACK if data came in.
+func (c *QuickAckConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 && c.rearm.Load() {
+		c.rc.Control(func(fd uintptr) {
+			c.set(fd)
+		})
+	}
+	return n, err
+}
+
+// SyscallConn returns the raw connection, so that Apply works on c.
+func (c *QuickAckConn) SyscallConn() (syscall.RawConn, error) {
+	return c.rc, nil
+}
@@ -0,0 +1,79 @@
+package socket
+
+import (
+	"io"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestQuickAckConn(t *testing.T) {
+	client, server := tcpPair(t)
+	c, err := NewQuickAckConn(client)
+	if err != nil {
+		t.Fatalf("NewQuickAckConn: %v", err)
+	}
+	if err := Apply(c, QuickAck(false)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	server.Write([]byte("x"))
+	if _, err := io.ReadFull(c, make([]byte, 1)); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if v := getsockopt(t, client, syscall.IPPROTO_TCP, syscall.TCP_QUICKACK); v != 1 {
+		t.Fatalf("expect: quickack re-armed; got: %d", v)
+	}
+
+	c.SetRearm(false)
+	Apply(c, QuickAck(false))
+	server.Write([]byte("x"))
+	io.ReadFull(c, make([]byte, 1))
+	if v := getsockopt(t, client, syscall.IPPROTO_TCP, syscall.TCP_QUICKACK); v != 0 {
+		t.Fatalf("expect: quickack left alone; got: %d", v)
+	}
+}
+
+// benchmarkRPC measures a request/response round trip where the server
+// answers in two writes, the pattern stalled by delayed ACKs combined with
+// Nagle's algorithm.
+func benchmarkRPC(b *testing.B, wrap func(net.Conn) net.Conn) {
+	client, server := tcpPair(b)
+	server.SetNoDelay(false)
+	go func() {
+		req := make([]byte, 64)
+		for {
+			if _, err := io.ReadFull(server, req); err != nil {
+				return
+			}
+			server.Write(req[:16])
+			server.Write(req[16:])
+		}
+	}()
+
+	conn := wrap(client)
+	buf := make([]byte, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := conn.Write(buf); err != nil {
+			b.Fatalf("write: %v", err)
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			b.Fatalf("read: %v", err)
+		}
+	}
+}
+
+func BenchmarkRPC(b *testing.B) {
+	b.Run("plain", func(b *testing.B) {
+		benchmarkRPC(b, func(c net.Conn) net.Conn { return c })
+	})
+	b.Run("quickack", func(b *testing.B) {
+		benchmarkRPC(b, func(c net.Conn) net.Conn {
+			qc, err := NewQuickAckConn(c)
+			if err != nil {
+				b.Fatalf("NewQuickAckConn: %v", err)
+			}
+			return qc
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package socket
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"syscall"
+)
+
+func bufferOption(name string, opt sockopt, size int) Option {
+	o := intOption(name, opt, size)
+	if size <= 0 || size > math.MaxInt32/2 {
+		return invalid(o, fmt.Errorf("%w: %d", ErrInvalidValue, size))
+	}
+	return o
+}
+
+// RecvBuffer sets SO_RCVBUF, the size of the receive buffer. The kernel
+// caps it at net.core.rmem_max and Linux doubles it, see SetRecvBuffer.
+func RecvBuffer(size int) Option {
+	return bufferOption("SO_RCVBUF", soRcvBuf, size)
+}
+
+// SendBuffer sets SO_SNDBUF, the size of the send buffer. The kernel caps it
+// at net.core.wmem_max and Linux doubles it, see SetSendBuffer.
+func SendBuffer(size int) Option {
+	return bufferOption("SO_SNDBUF", soSndBuf, size)
+}
+
+// RecvBufferForce sets SO_RCVBUFFORCE, like RecvBuffer without the cap. It
+// needs CAP_NET_ADMIN. Linux only.
+func RecvBufferForce(size int) Option {
+	return bufferOption("SO_RCVBUFFORCE", soRcvBufForce, size)
+}
+
+// SendBufferForce sets SO_SNDBUFFORCE, like SendBuffer without the cap. It
+// needs CAP_NET_ADMIN. Linux only.
+func SendBufferForce(size int) Option {
+	return bufferOption("SO_SNDBUFFORCE", soSndBufForce, size)
+}
+
+// GetRecvBuffer returns the effective SO_RCVBUF of conn.
+func GetRecvBuffer(conn net.Conn) (int, error) {
+	return getInt(conn, "SO_RCVBUF", soRcvBuf)
+}
+
+// GetSendBuffer returns the effective SO_SNDBUF of conn.
+func GetSendBuffer(conn net.Conn) (int, error) {
+	return getInt(conn, "SO_SNDBUF", soSndBuf)
+}
+
+// BufferSize reports the outcome of SetRecvBuffer or SetSendBuffer.
+type BufferSize struct {
+	// Requested is the size asked for.
+	Requested int
+
+	// Effective is the size read back from the kernel. Linux reports
+	// twice the size it grants, half of it kept for bookkeeping.
+	Effective int
+
+	// Forced reports whether the size was set past the system cap.
+	Forced bool
+}
+
+// Clamped reports whether the kernel did not grant the requested size, either
+// capping it at the system maximum or raising it to the minimum.
+func (b BufferSize) Clamped() bool {
+	return b.Effective != b.Requested*bufferScale
+}
+
+func (b BufferSize) String() string {
+	s := fmt.Sprintf("requested %d, effective %d", b.Requested, b.Effective)
+	if b.Forced {
+		s += ", forced"
+	}
+	if b.Clamped() {
+		s += ", clamped"
+	}
+	return s
+}
+
+// SetRecvBuffer sets the receive buffer of conn to size bytes and reads back
+// the size the kernel granted. With force it tries SO_RCVBUFFORCE first,
+// falling back to SO_RCVBUF without the privilege.
+func SetRecvBuffer(conn net.Conn, size int, force bool) (BufferSize, error) {
+	return setBuffer(conn, size, force, RecvBuffer(size), RecvBufferForce(size), soRcvBuf)
+}
+
+// SetSendBuffer sets the send buffer of conn to size bytes and reads back the
+// size the kernel granted. With force it tries SO_SNDBUFFORCE first, falling
+// back to SO_SNDBUF without the privilege.
+func SetSendBuffer(conn net.Conn, size int, force bool) (BufferSize, error) {
+	return setBuffer(conn, size, force, SendBuffer(size), SendBufferForce(size), soSndBuf)
+}
+
+func setBuffer(conn net.Conn, size int, force bool, plain, forced Option, opt sockopt) (BufferSize, error) {
+	b := BufferSize{Requested: size}
+	var err error
+	if force && forced.Supported() {
+		err = Apply(conn, forced)
+		b.Forced = err == nil
+	}
+	if !b.Forced && (err == nil || errors.Is(err, syscall.EPERM)) {
+		err = Apply(conn, plain)
+	}
+	if err != nil {
+		return b, err
+	}
+	b.Effective, err = getInt(conn, plain.name, opt)
+	return b, err
+}
@@ -0,0 +1,81 @@
+package socket
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sysctl reads an integer from /proc/sys.
+func sysctl(t *testing.T, name string) int {
+	b, err := os.ReadFile("/proc/sys/" + strings.ReplaceAll(name, ".", "/"))
+	if err != nil {
+		t.Skipf("sysctl: %v", err)
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Skipf("sysctl %s: %v", name, err)
+	}
+	return v
+}
+
+func TestSetBuffer(t *testing.T) {
+	client, _ := tcpPair(t)
+	b, err := SetRecvBuffer(client, 64<<10, false)
+	if err != nil {
+		t.Fatalf("SetRecvBuffer: %v", err)
+	}
+	if b.Effective != 128<<10 || b.Clamped() || b.Forced {
+		t.Fatalf("expect: effective %d, not clamped; got: %s", 128<<10, b)
+	}
+	if v, err := GetRecvBuffer(client); err != nil || v != b.Effective {
+		t.Fatalf("expect: SO_RCVBUF=%d; got: %d, %v", b.Effective, v, err)
+	}
+
+	// Past the cap the kernel silently clamps.
+	max := sysctl(t, "net.core.wmem_max")
+	b, err = SetSendBuffer(client, max+64<<10, false)
+	if err != nil {
+		t.Fatalf("SetSendBuffer: %v", err)
+	}
+	if b.Effective != 2*max || !b.Clamped() {
+		t.Fatalf("expect: effective %d, clamped; got: %s", 2*max, b)
+	}
+
+	// Below the minimum the kernel raises it.
+	if b, err = SetSendBuffer(client, 1, false); err != nil || !b.Clamped() || b.Effective <= 2 {
+		t.Fatalf("expect: raised to the minimum; got: %s, %v", b, err)
+	}
+}
+
+func TestSetBufferForce(t *testing.T) {
+	client, _ := tcpPair(t)
+	max := sysctl(t, "net.core.rmem_max")
+	size := max + 64<<10
+	b, err := SetRecvBuffer(client, size, true)
+	if err != nil {
+		t.Fatalf("SetRecvBuffer: %v", err)
+	}
+	if !b.Forced {
+		// Without CAP_NET_ADMIN it falls back to the capped size.
+		if b.Effective != 2*max || !b.Clamped() {
+			t.Fatalf("expect: effective %d, clamped; got: %s", 2*max, b)
+		}
+		t.Skipf("SO_RCVBUFFORCE not permitted")
+	}
+	if b.Effective != 2*size || b.Clamped() {
+		t.Fatalf("expect: effective %d, not clamped; got: %s", 2*size, b)
+	}
+}
+
+func TestBufferInvalid(t *testing.T) {
+	client, _ := tcpPair(t)
+	for _, size := range []int{0, -1, math.MaxInt32} {
+		if _, err := SetRecvBuffer(client, size, true); !errors.Is(err, ErrInvalidValue) {
+			t.Fatalf("case: %d  expect: %v; got: %v", size, ErrInvalidValue, err)
+		}
+	}
+}
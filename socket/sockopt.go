@@ -0,0 +1,215 @@
+package socket
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrUnsupported is returned for options the platform does not support. It
+// wraps errors.ErrUnsupported.
+var ErrUnsupported = fmt.Errorf("socket: option not supported on this platform: %w", errors.ErrUnsupported)
+
+// ErrInvalidValue is returned for options given an out of range value.
+var ErrInvalidValue = errors.New("socket: invalid option value")
+
+// OptionError records the option that failed to apply and why.
+type OptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return "socket: " + e.Option + ": " + e.Err.Error()
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// sockopt identifies a socket option on the platform. The zero value stands
+// for an option the platform does not support.
+type sockopt struct {
+	level, name int
+	ok          bool
+}
+
+// setter applies an option to a socket. It is nil when the platform does not
+// support the option.
+type setter func(fd uintptr) error
+
+// Option is a socket option applied to a connection by Apply.
+type Option struct {
+	name string
+	set  setter
+	err  error
+}
+
+// Name returns the name of the option, e.g. "TCP_QUICKACK".
+func (o Option) Name() string {
+	return o.name
+}
+
+// Supported reports whether the platform supports the option.
+func (o Option) Supported() bool {
+	return o.set != nil
+}
+
+func intOption(name string, opt sockopt, v int) Option {
+	o := Option{name: name}
+	if opt.ok {
+		o.set = func(fd uintptr) error {
+			return setsockoptInt(fd, opt.level, opt.name, v)
+		}
+	}
+	return o
+}
+
+func boolOption(name string, opt sockopt, on bool) Option {
+	v := 0
+	if on {
+		v = 1
+	}
+	return intOption(name, opt, v)
+}
+
+func stringOption(name string, opt sockopt, v string) Option {
+	o := Option{name: name}
+	if opt.ok {
+		o.set = func(fd uintptr) error {
+			return setsockoptString(fd, opt.level, opt.name, v)
+		}
+	}
+	return o
+}
+
+// invalid returns an option failing with ErrInvalidValue, or with
+// ErrUnsupported first if the platform does not support it anyway.
+func invalid(o Option, err error) Option {
+	if o.set != nil {
+		o.err = err
+	}
+	return o
+}
+
+// Options is a set of socket options applied together.
+type Options []Option
+
+// Apply applies the options in order to conn, which must expose its socket
+// like *net.TCPConn does. It stops at the first failure and returns an
+// *OptionError, wrapping ErrUnsupported if the platform does not support the
+// option or ErrInvalidValue if the value is out of range.
+func (opts Options) Apply(conn net.Conn) error {
+	for _, o := range opts {
+		if err := o.check(); err != nil {
+			return err
+		}
+		if err := control(conn, o.set); err != nil {
+			return &OptionError{Option: o.name, Err: err}
+		}
+	}
+	return nil
+}
+
+// check reports why o cannot be applied, if so.
+func (o Option) check() error {
+	if o.set == nil {
+		return &OptionError{Option: o.name, Err: ErrUnsupported}
+	}
+	if o.err != nil {
+		return &OptionError{Option: o.name, Err: o.err}
+	}
+	return nil
+}
+
+// Control applies the options in order to the socket behind rc, like Apply.
+// It suits the Control hooks of net.ListenConfig and net.Dialer, which run
+// before the socket is bound:
+//
+//	lc := net.ListenConfig{Control: func(_, _ string, rc syscall.RawConn) error {
+//		return opts.Control(rc)
+//	}}
+func (opts Options) Control(rc syscall.RawConn) error {
+	for _, o := range opts {
+		if err := o.check(); err != nil {
+			return err
+		}
+		if err := rawControl(rc, o.set); err != nil {
+			return &OptionError{Option: o.name, Err: err}
+		}
+	}
+	return nil
+}
+
+// Apply applies opts in order to conn, see Options.Apply.
+func Apply(conn net.Conn, opts ...Option) error {
+	return Options(opts).Apply(conn)
+}
+
+// getInt reads an integer option of conn.
+func getInt(conn net.Conn, name string, opt sockopt) (int, error) {
+	if !opt.ok {
+		return 0, &OptionError{Option: name, Err: ErrUnsupported}
+	}
+	var v int
+	err := control(conn, func(fd uintptr) error {
+		var err error
+		v, err = getsockoptInt(fd, opt.level, opt.name)
+		return err
+	})
+	if err != nil {
+		return 0, &OptionError{Option: name, Err: err}
+	}
+	return v, nil
+}
+
+// getString reads a string option of conn.
+func getString(conn net.Conn, name string, opt sockopt) (string, error) {
+	if !opt.ok {
+		return "", &OptionError{Option: name, Err: ErrUnsupported}
+	}
+	var v string
+	err := control(conn, func(fd uintptr) error {
+		var err error
+		v, err = getsockoptString(fd, opt.level, opt.name)
+		return err
+	})
+	if err != nil {
+		return "", &OptionError{Option: name, Err: err}
+	}
+	return v, nil
+}
+
+// Capabilities reports, by option name, whether the platform supports each
+// option of the package.
+func Capabilities() map[string]bool {
+	res := map[string]bool{}
+	for _, o := range []Option{
+		QuickAck(true),
+		NoDelay(true),
+		Cork(true),
+		KeepAlive(true),
+		KeepAliveIdle(1),
+		KeepAliveInterval(1),
+		KeepAliveCount(1),
+		UserTimeout(0),
+		NotSentLowat(0),
+		Congestion("cubic"),
+		ReuseAddr(true),
+		ReusePort(true),
+		FastOpen(1),
+		DeferAccept(1),
+		BindToDevice("lo"),
+		Mark(1),
+		BindAddressNoPort(true),
+		RecvBuffer(1),
+		SendBuffer(1),
+		RecvBufferForce(1),
+		SendBufferForce(1),
+		ZeroCopy(true),
+	} {
+		res[o.name] = o.Supported()
+	}
+	return res
+}
@@ -1,7 +1,31 @@
-package sockopt
+package socket
 
-import "net"
+import "golang.org/x/sys/unix"
 
-func SetQuickAck(conn *net.TCPConn) error {
-	return nil
-}
+var (
+	soKeepAlive     = sockopt{unix.SOL_SOCKET, unix.SO_KEEPALIVE, true}
+	tcpQuickAck     sockopt
+	tcpNoDelay      = sockopt{unix.IPPROTO_TCP, unix.TCP_NODELAY, true}
+	tcpCork         sockopt
+	tcpKeepIdle     = sockopt{unix.IPPROTO_TCP, unix.TCP_KEEPALIVE, true}
+	tcpKeepIntvl    = sockopt{unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, true}
+	tcpKeepCnt      = sockopt{unix.IPPROTO_TCP, unix.TCP_KEEPCNT, true}
+	tcpUserTimeout  sockopt
+	tcpNotSentLowat = sockopt{unix.IPPROTO_TCP, unix.TCP_NOTSENT_LOWAT, true}
+	tcpCongestion   sockopt
+	soReuseAddr     = sockopt{unix.SOL_SOCKET, unix.SO_REUSEADDR, true}
+	soReusePort     = sockopt{unix.SOL_SOCKET, unix.SO_REUSEPORT, true}
+	tcpFastOpen     = sockopt{unix.IPPROTO_TCP, unix.TCP_FASTOPEN, true}
+	tcpDeferAccept  sockopt
+	soBindToDevice  sockopt
+	soMark          sockopt
+	ipBindNoPort    sockopt
+	soRcvBuf        = sockopt{unix.SOL_SOCKET, unix.SO_RCVBUF, true}
+	soSndBuf        = sockopt{unix.SOL_SOCKET, unix.SO_SNDBUF, true}
+	soRcvBufForce   sockopt
+	soSndBufForce   sockopt
+	soZeroCopy      sockopt
+)
+
+// bufferScale is the factor the kernel applies to socket buffer sizes.
+const bufferScale = 1
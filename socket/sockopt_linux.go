@@ -1,24 +1,32 @@
 package socket
 
-import (
-	"net"
-	"os"
-	"syscall"
+import "golang.org/x/sys/unix"
 
-	"golang.org/x/sys/unix"
+var (
+	soKeepAlive     = sockopt{unix.SOL_SOCKET, unix.SO_KEEPALIVE, true}
+	tcpQuickAck     = sockopt{unix.IPPROTO_TCP, unix.TCP_QUICKACK, true}
+	tcpNoDelay      = sockopt{unix.IPPROTO_TCP, unix.TCP_NODELAY, true}
+	tcpCork         = sockopt{unix.IPPROTO_TCP, unix.TCP_CORK, true}
+	tcpKeepIdle     = sockopt{unix.IPPROTO_TCP, unix.TCP_KEEPIDLE, true}
+	tcpKeepIntvl    = sockopt{unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, true}
+	tcpKeepCnt      = sockopt{unix.IPPROTO_TCP, unix.TCP_KEEPCNT, true}
+	tcpUserTimeout  = sockopt{unix.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, true}
+	tcpNotSentLowat = sockopt{unix.IPPROTO_TCP, unix.TCP_NOTSENT_LOWAT, true}
+	tcpCongestion   = sockopt{unix.IPPROTO_TCP, unix.TCP_CONGESTION, true}
+	soReuseAddr     = sockopt{unix.SOL_SOCKET, unix.SO_REUSEADDR, true}
+	soReusePort     = sockopt{unix.SOL_SOCKET, unix.SO_REUSEPORT, true}
+	tcpFastOpen     = sockopt{unix.IPPROTO_TCP, unix.TCP_FASTOPEN, true}
+	tcpDeferAccept  = sockopt{unix.IPPROTO_TCP, unix.TCP_DEFER_ACCEPT, true}
+	soBindToDevice  = sockopt{unix.SOL_SOCKET, unix.SO_BINDTODEVICE, true}
+	soMark          = sockopt{unix.SOL_SOCKET, unix.SO_MARK, true}
+	ipBindNoPort    = sockopt{unix.IPPROTO_IP, unix.IP_BIND_ADDRESS_NO_PORT, true}
+	soRcvBuf        = sockopt{unix.SOL_SOCKET, unix.SO_RCVBUF, true}
+	soSndBuf        = sockopt{unix.SOL_SOCKET, unix.SO_SNDBUF, true}
+	soRcvBufForce   = sockopt{unix.SOL_SOCKET, unix.SO_RCVBUFFORCE, true}
+	soSndBufForce   = sockopt{unix.SOL_SOCKET, unix.SO_SNDBUFFORCE, true}
+	soZeroCopy      = sockopt{unix.SOL_SOCKET, unix.SO_ZEROCOPY, true}
 )
 
-func SetQuickAck(conn *net.TCPConn) error {
-	var f *os.File
-	var err error
-	if f, err = conn.File(); err != nil {
-		return err
-	}
-	defer f.Close()
-
-	fd := int(f.Fd())
-	if err := unix.SetNonblock(fd, true); err != nil {
-		return err
-	}
-	return syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_QUICKACK, 1)
-}
+// bufferScale is the factor the kernel applies to the requested socket
+// buffer sizes, doubling them to leave room for bookkeeping.
+const bufferScale = 2
@@ -0,0 +1,43 @@
+package socket
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+// getsockopt reads an integer option of conn.
+func getsockopt(t *testing.T, conn *net.TCPConn, level, opt int) int {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn: %v", err)
+	}
+	var v int
+	var serr error
+	rc.Control(func(fd uintptr) {
+		v, serr = syscall.GetsockoptInt(int(fd), level, opt)
+	})
+	if serr != nil {
+		t.Fatalf("getsockopt: %v", serr)
+	}
+	return v
+}
+
+func TestQuickAck(t *testing.T) {
+	client, _ := tcpPair(t)
+	if !Capabilities()["TCP_QUICKACK"] {
+		t.Fatalf("expect: TCP_QUICKACK supported on linux")
+	}
+	if err := Apply(client, QuickAck(false)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if v := getsockopt(t, client, syscall.IPPROTO_TCP, syscall.TCP_QUICKACK); v != 0 {
+		t.Fatalf("expect: quickack=0; got: %d", v)
+	}
+	if err := SetQuickAck(client); err != nil {
+		t.Fatalf("SetQuickAck: %v", err)
+	}
+	if v := getsockopt(t, client, syscall.IPPROTO_TCP, syscall.TCP_QUICKACK); v != 1 {
+		t.Fatalf("expect: quickack=1; got: %d", v)
+	}
+}
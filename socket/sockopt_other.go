@@ -0,0 +1,32 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package socket
+
+var (
+	soKeepAlive     sockopt
+	tcpQuickAck     sockopt
+	tcpNoDelay      sockopt
+	tcpCork         sockopt
+	tcpKeepIdle     sockopt
+	tcpKeepIntvl    sockopt
+	tcpKeepCnt      sockopt
+	tcpUserTimeout  sockopt
+	tcpNotSentLowat sockopt
+	tcpCongestion   sockopt
+	soReuseAddr     sockopt
+	soReusePort     sockopt
+	tcpFastOpen     sockopt
+	tcpDeferAccept  sockopt
+	soBindToDevice  sockopt
+	soMark          sockopt
+	ipBindNoPort    sockopt
+	soRcvBuf        sockopt
+	soSndBuf        sockopt
+	soRcvBufForce   sockopt
+	soSndBufForce   sockopt
+	soZeroCopy      sockopt
+)
+
+// bufferScale is the factor the kernel applies to socket buffer sizes.
+const bufferScale = 1
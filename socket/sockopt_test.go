@@ -0,0 +1,60 @@
+package socket
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair(t testing.TB) (client, server *net.TCPConn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := ln.Accept()
+		accepted <- conn
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	peer := <-accepted
+	if peer == nil {
+		t.Fatalf("accept failed")
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		peer.Close()
+	})
+	return conn.(*net.TCPConn), peer.(*net.TCPConn)
+}
+
+func TestApplyUnsupported(t *testing.T) {
+	client, _ := tcpPair(t)
+	err := Apply(client, Option{name: "TCP_NOTHING"})
+	var oe *OptionError
+	if !errors.As(err, &oe) || oe.Option != "TCP_NOTHING" || !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expect: %v for TCP_NOTHING; got: %v", ErrUnsupported, err)
+	}
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	if err := Apply(c1, QuickAck(true)); !errors.Is(err, ErrUnsupported) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expect: %v on a pipe; got: %v", ErrUnsupported, err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	caps := Capabilities()
+	for _, o := range []Option{QuickAck(true), NoDelay(true), Cork(true), UserTimeout(0), Congestion("reno")} {
+		if supported, ok := caps[o.Name()]; !ok || supported != o.Supported() {
+			t.Fatalf("expect: %s reported as %v; got: %v, %v", o.Name(), o.Supported(), supported, ok)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package socket
+
+func setsockoptInt(fd uintptr, level, name, v int) error {
+	return ErrUnsupported
+}
+
+func getsockoptInt(fd uintptr, level, name int) (int, error) {
+	return 0, ErrUnsupported
+}
+
+func setsockoptString(fd uintptr, level, name int, v string) error {
+	return ErrUnsupported
+}
+
+func getsockoptString(fd uintptr, level, name int) (string, error) {
+	return "", ErrUnsupported
+}
+
+func listen(fd uintptr, backlog int) error {
+	return ErrUnsupported
+}
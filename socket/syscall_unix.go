@@ -0,0 +1,26 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package socket
+
+import "golang.org/x/sys/unix"
+
+func setsockoptInt(fd uintptr, level, name, v int) error {
+	return unix.SetsockoptInt(int(fd), level, name, v)
+}
+
+func getsockoptInt(fd uintptr, level, name int) (int, error) {
+	return unix.GetsockoptInt(int(fd), level, name)
+}
+
+func setsockoptString(fd uintptr, level, name int, v string) error {
+	return unix.SetsockoptString(int(fd), level, name, v)
+}
+
+func getsockoptString(fd uintptr, level, name int) (string, error) {
+	return unix.GetsockoptString(int(fd), level, name)
+}
+
+func listen(fd uintptr, backlog int) error {
+	return unix.Listen(int(fd), backlog)
+}
@@ -0,0 +1,164 @@
+package socket
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"time"
+)
+
+// QuickAck sets TCP_QUICKACK, sending ACKs right away instead of delaying
+// them. Linux only; see QuickAckConn to keep it armed.
+func QuickAck(on bool) Option {
+	return boolOption("TCP_QUICKACK", tcpQuickAck, on)
+}
+
+// SetQuickAck sets TCP_QUICKACK on conn.
+func SetQuickAck(conn *net.TCPConn) error {
+	return Apply(conn, QuickAck(true))
+}
+
+// GetQuickAck reports whether TCP_QUICKACK is set on conn.
+func GetQuickAck(conn net.Conn) (bool, error) {
+	v, err := getInt(conn, "TCP_QUICKACK", tcpQuickAck)
+	return v != 0, err
+}
+
+// NoDelay sets TCP_NODELAY, disabling Nagle's algorithm.
+func NoDelay(on bool) Option {
+	return boolOption("TCP_NODELAY", tcpNoDelay, on)
+}
+
+// GetNoDelay reports whether TCP_NODELAY is set on conn.
+func GetNoDelay(conn net.Conn) (bool, error) {
+	v, err := getInt(conn, "TCP_NODELAY", tcpNoDelay)
+	return v != 0, err
+}
+
+// Cork sets TCP_CORK, holding partial frames until it is cleared. Linux
+// only.
+func Cork(on bool) Option {
+	return boolOption("TCP_CORK", tcpCork, on)
+}
+
+// GetCork reports whether TCP_CORK is set on conn.
+func GetCork(conn net.Conn) (bool, error) {
+	v, err := getInt(conn, "TCP_CORK", tcpCork)
+	return v != 0, err
+}
+
+// KeepAlive sets SO_KEEPALIVE, enabling keepalive probes.
+func KeepAlive(on bool) Option {
+	return boolOption("SO_KEEPALIVE", soKeepAlive, on)
+}
+
+// GetKeepAlive reports whether SO_KEEPALIVE is set on conn.
+func GetKeepAlive(conn net.Conn) (bool, error) {
+	v, err := getInt(conn, "SO_KEEPALIVE", soKeepAlive)
+	return v != 0, err
+}
+
+// maxKeepAlive is the largest keepalive time accepted by Linux, in seconds.
+const maxKeepAlive = 32767
+
+// seconds converts d to whole seconds within [1, maxKeepAlive].
+func seconds(d time.Duration) (int, error) {
+	if d <= 0 || d > maxKeepAlive*time.Second {
+		return 0, fmt.Errorf("%w: %v not within [1s, %ds]", ErrInvalidValue, d, maxKeepAlive)
+	}
+	return int((d + time.Second - 1) / time.Second), nil
+}
+
+// KeepAliveIdle sets TCP_KEEPIDLE, the idle time before the first keepalive
+// probe, rounded up to the second. It is TCP_KEEPALIVE on darwin.
+func KeepAliveIdle(d time.Duration) Option {
+	s, err := seconds(d)
+	return invalid(intOption("TCP_KEEPIDLE", tcpKeepIdle, s), err)
+}
+
+// GetKeepAliveIdle returns the TCP_KEEPIDLE of conn.
+func GetKeepAliveIdle(conn net.Conn) (time.Duration, error) {
+	v, err := getInt(conn, "TCP_KEEPIDLE", tcpKeepIdle)
+	return time.Duration(v) * time.Second, err
+}
+
+// KeepAliveInterval sets TCP_KEEPINTVL, the time between keepalive probes,
+// rounded up to the second.
+func KeepAliveInterval(d time.Duration) Option {
+	s, err := seconds(d)
+	return invalid(intOption("TCP_KEEPINTVL", tcpKeepIntvl, s), err)
+}
+
+// GetKeepAliveInterval returns the TCP_KEEPINTVL of conn.
+func GetKeepAliveInterval(conn net.Conn) (time.Duration, error) {
+	v, err := getInt(conn, "TCP_KEEPINTVL", tcpKeepIntvl)
+	return time.Duration(v) * time.Second, err
+}
+
+// KeepAliveCount sets TCP_KEEPCNT, the number of unanswered probes before
+// the connection is dropped, within [1, 127].
+func KeepAliveCount(n int) Option {
+	o := intOption("TCP_KEEPCNT", tcpKeepCnt, n)
+	if n < 1 || n > 127 {
+		return invalid(o, fmt.Errorf("%w: %d not within [1, 127]", ErrInvalidValue, n))
+	}
+	return o
+}
+
+// GetKeepAliveCount returns the TCP_KEEPCNT of conn.
+func GetKeepAliveCount(conn net.Conn) (int, error) {
+	return getInt(conn, "TCP_KEEPCNT", tcpKeepCnt)
+}
+
+// UserTimeout sets TCP_USER_TIMEOUT, the time transmitted data may stay
+// unacknowledged before the connection is dropped, rounded down to the
+// millisecond. Zero restores the system default. Linux only.
+func UserTimeout(d time.Duration) Option {
+	ms := d / time.Millisecond
+	o := intOption("TCP_USER_TIMEOUT", tcpUserTimeout, int(ms))
+	if d < 0 || ms > math.MaxInt32 {
+		return invalid(o, fmt.Errorf("%w: %v", ErrInvalidValue, d))
+	}
+	return o
+}
+
+// GetUserTimeout returns the TCP_USER_TIMEOUT of conn.
+func GetUserTimeout(conn net.Conn) (time.Duration, error) {
+	v, err := getInt(conn, "TCP_USER_TIMEOUT", tcpUserTimeout)
+	return time.Duration(v) * time.Millisecond, err
+}
+
+// NotSentLowat sets TCP_NOTSENT_LOWAT, the amount of unsent data above which
+// the socket stops reporting itself writable.
+func NotSentLowat(n int) Option {
+	o := intOption("TCP_NOTSENT_LOWAT", tcpNotSentLowat, n)
+	if n < 0 || n > math.MaxInt32 {
+		return invalid(o, fmt.Errorf("%w: %d", ErrInvalidValue, n))
+	}
+	return o
+}
+
+// GetNotSentLowat returns the TCP_NOTSENT_LOWAT of conn.
+func GetNotSentLowat(conn net.Conn) (int, error) {
+	return getInt(conn, "TCP_NOTSENT_LOWAT", tcpNotSentLowat)
+}
+
+// maxCongestion is the longest congestion control name, TCP_CA_NAME_MAX
+// without the trailing NUL.
+const maxCongestion = 15
+
+// Congestion sets TCP_CONGESTION, the congestion control algorithm, e.g.
+// "cubic" or "bbr". The algorithm must be available in the kernel. Linux
+// only.
+func Congestion(name string) Option {
+	o := stringOption("TCP_CONGESTION", tcpCongestion, name)
+	if name == "" || len(name) > maxCongestion {
+		return invalid(o, fmt.Errorf("%w: %q", ErrInvalidValue, name))
+	}
+	return o
+}
+
+// GetCongestion returns the TCP_CONGESTION of conn.
+func GetCongestion(conn net.Conn) (string, error) {
+	return getString(conn, "TCP_CONGESTION", tcpCongestion)
+}
@@ -0,0 +1,70 @@
+package socket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestTCPRoundTrip(t *testing.T) {
+	client, _ := tcpPair(t)
+	err := Apply(client,
+		NoDelay(false),
+		Cork(true),
+		KeepAlive(true),
+		KeepAliveIdle(90*time.Second),
+		KeepAliveInterval(1500*time.Millisecond),
+		KeepAliveCount(7),
+		UserTimeout(2500*time.Millisecond),
+		NotSentLowat(16384),
+		Congestion("reno"),
+	)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	for _, c := range []struct {
+		name string
+		get  func() (any, error)
+		want any
+	}{
+		{"TCP_NODELAY", func() (any, error) { return GetNoDelay(client) }, false},
+		{"TCP_CORK", func() (any, error) { return GetCork(client) }, true},
+		{"SO_KEEPALIVE", func() (any, error) { return GetKeepAlive(client) }, true},
+		{"TCP_KEEPIDLE", func() (any, error) { return GetKeepAliveIdle(client) }, 90 * time.Second},
+		{"TCP_KEEPINTVL", func() (any, error) { return GetKeepAliveInterval(client) }, 2 * time.Second},
+		{"TCP_KEEPCNT", func() (any, error) { return GetKeepAliveCount(client) }, 7},
+		{"TCP_USER_TIMEOUT", func() (any, error) { return GetUserTimeout(client) }, 2500 * time.Millisecond},
+		{"TCP_NOTSENT_LOWAT", func() (any, error) { return GetNotSentLowat(client) }, 16384},
+		{"TCP_CONGESTION", func() (any, error) { return GetCongestion(client) }, "reno"},
+	} {
+		got, err := c.get()
+		if err != nil || got != c.want {
+			t.Fatalf("case: %s  expect: %v; got: %v, %v", c.name, c.want, got, err)
+		}
+	}
+	if v := getsockopt(t, client, unix.IPPROTO_TCP, unix.TCP_KEEPINTVL); v != 2 {
+		t.Fatalf("expect: keepintvl=2; got: %d", v)
+	}
+
+	if err := Apply(client, NoDelay(true), Cork(false)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if on, err := GetNoDelay(client); err != nil || !on {
+		t.Fatalf("expect: nodelay on; got: %v, %v", on, err)
+	}
+	if on, err := GetCork(client); err != nil || on {
+		t.Fatalf("expect: cork off; got: %v, %v", on, err)
+	}
+}
+
+func TestCongestionUnknown(t *testing.T) {
+	client, _ := tcpPair(t)
+	err := Apply(client, Congestion("nonexistent"))
+	var oe *OptionError
+	if !errors.As(err, &oe) || oe.Option != "TCP_CONGESTION" || !errors.Is(err, unix.ENOENT) {
+		t.Fatalf("expect: %v for TCP_CONGESTION; got: %v", unix.ENOENT, err)
+	}
+}
@@ -0,0 +1,52 @@
+package socket
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestInvalidValue(t *testing.T) {
+	client, _ := tcpPair(t)
+	for _, o := range []Option{
+		KeepAliveIdle(0),
+		KeepAliveIdle(-time.Second),
+		KeepAliveIdle(40000 * time.Second),
+		KeepAliveIdle(math.MaxInt64),
+		KeepAliveInterval(0),
+		KeepAliveCount(0),
+		KeepAliveCount(128),
+		UserTimeout(-time.Millisecond),
+		NotSentLowat(-1),
+		Congestion(""),
+		Congestion("a-very-long-algorithm"),
+	} {
+		err := Apply(client, o)
+		if !o.Supported() {
+			if !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("case: %s  expect: %v; got: %v", o.Name(), ErrUnsupported, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidValue) {
+			t.Fatalf("case: %s  expect: %v; got: %v", o.Name(), ErrInvalidValue, err)
+		}
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	for _, c := range []struct {
+		d    time.Duration
+		want int
+	}{
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Millisecond, 1},
+		{maxKeepAlive * time.Second, maxKeepAlive},
+	} {
+		if got, err := seconds(c.d); err != nil || got != c.want {
+			t.Fatalf("case: %v  expect: %d; got: %d, %v", c.d, c.want, got, err)
+		}
+	}
+}
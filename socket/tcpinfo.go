@@ -0,0 +1,91 @@
+package socket
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TCPState is the state of a TCP connection, as in tcp_states.h.
+type TCPState uint8
+
+var tcpStates = [...]string{
+	"", "ESTABLISHED", "SYN_SENT", "SYN_RECV", "FIN_WAIT1", "FIN_WAIT2",
+	"TIME_WAIT", "CLOSE", "CLOSE_WAIT", "LAST_ACK", "LISTEN", "CLOSING",
+}
+
+func (s TCPState) String() string {
+	if int(s) < len(tcpStates) && s > 0 {
+		return tcpStates[s]
+	}
+	return "STATE(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Info holds the diagnostics of a TCP connection returned by TCPInfo. Older
+// kernels return a shorter tcp_info: Len is the number of bytes the kernel
+// filled in, and fields beyond it are left zero.
+type Info struct {
+	Len int
+
+	State       TCPState
+	CAState     uint8
+	Retransmits uint8 // unrecovered timeouts of the current segment
+	Probes      uint8
+	Backoff     uint8
+
+	RTO    time.Duration
+	RTT    time.Duration // smoothed round trip time
+	RTTVar time.Duration
+	MinRTT time.Duration
+
+	SndMSS       uint32
+	RcvMSS       uint32
+	Unacked      uint32 // segments in flight
+	Lost         uint32
+	Retrans      uint32 // segments being retransmitted
+	TotalRetrans uint32 // segments retransmitted over the connection
+	SndCwnd      uint32 // congestion window, in segments
+	SndSsthresh  uint32
+	Reordering   uint32
+
+	LastDataSent time.Duration // since the last data was sent
+	LastDataRecv time.Duration // since the last data was received
+	LastAckRecv  time.Duration
+
+	PacingRate    uint64 // bytes per second
+	DeliveryRate  uint64 // bytes per second
+	BytesSent     uint64
+	BytesRetrans  uint64
+	BytesAcked    uint64
+	BytesReceived uint64
+	SegsOut       uint32
+	SegsIn        uint32
+	NotSentBytes  uint32
+
+	BusyTime      time.Duration // time with data in flight
+	RwndLimited   time.Duration // time limited by the receive window
+	SndbufLimited time.Duration // time limited by the send buffer
+}
+
+// String formats the most useful fields on one line, for logging.
+func (i *Info) String() string {
+	return fmt.Sprintf("state=%s rtt=%v rttvar=%v minrtt=%v rto=%v cwnd=%d ssthresh=%d "+
+		"unacked=%d lost=%d retrans=%d/%d delivery=%s pacing=%s sent=%d acked=%d received=%d notsent=%d",
+		i.State, i.RTT, i.RTTVar, i.MinRTT, i.RTO, i.SndCwnd, i.SndSsthresh,
+		i.Unacked, i.Lost, i.Retrans, i.TotalRetrans, rate(i.DeliveryRate), rate(i.PacingRate),
+		i.BytesSent, i.BytesAcked, i.BytesReceived, i.NotSentBytes)
+}
+
+// rate formats a rate in bytes per second as bits per second.
+func rate(bps uint64) string {
+	bits := float64(bps) * 8
+	switch {
+	case bits >= 1e9:
+		return strconv.FormatFloat(bits/1e9, 'f', 2, 64) + "Gbps"
+	case bits >= 1e6:
+		return strconv.FormatFloat(bits/1e6, 'f', 2, 64) + "Mbps"
+	case bits >= 1e3:
+		return strconv.FormatFloat(bits/1e3, 'f', 2, 64) + "Kbps"
+	}
+	return strconv.FormatFloat(bits, 'f', 0, 64) + "bps"
+}
@@ -0,0 +1,78 @@
+package socket
+
+import (
+	"net"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// TCPInfo returns the TCP_INFO diagnostics of conn.
+func TCPInfo(conn net.Conn) (*Info, error) {
+	var raw unix.TCPInfo
+	n := uint32(unsafe.Sizeof(raw))
+	err := control(conn, func(fd uintptr) error {
+		_, _, errno := unix.Syscall6(unix.SYS_GETSOCKOPT, fd, unix.IPPROTO_TCP, unix.TCP_INFO,
+			uintptr(unsafe.Pointer(&raw)), uintptr(unsafe.Pointer(&n)), 0)
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &OptionError{Option: "TCP_INFO", Err: err}
+	}
+	return parseTCPInfo(unsafe.Slice((*byte)(unsafe.Pointer(&raw)), n)), nil
+}
+
+// parseTCPInfo decodes a tcp_info of any length, leaving the fields it does
+// not cover zero.
+func parseTCPInfo(b []byte) *Info {
+	var raw unix.TCPInfo
+	n := copy(unsafe.Slice((*byte)(unsafe.Pointer(&raw)), unsafe.Sizeof(raw)), b)
+	usec := func(v uint32) time.Duration { return time.Duration(v) * time.Microsecond }
+	msec := func(v uint32) time.Duration { return time.Duration(v) * time.Millisecond }
+	return &Info{
+		Len: n,
+
+		State:       TCPState(raw.State),
+		CAState:     raw.Ca_state,
+		Retransmits: raw.Retransmits,
+		Probes:      raw.Probes,
+		Backoff:     raw.Backoff,
+
+		RTO:    usec(raw.Rto),
+		RTT:    usec(raw.Rtt),
+		RTTVar: usec(raw.Rttvar),
+		MinRTT: usec(raw.Min_rtt),
+
+		SndMSS:       raw.Snd_mss,
+		RcvMSS:       raw.Rcv_mss,
+		Unacked:      raw.Unacked,
+		Lost:         raw.Lost,
+		Retrans:      raw.Retrans,
+		TotalRetrans: raw.Total_retrans,
+		SndCwnd:      raw.Snd_cwnd,
+		SndSsthresh:  raw.Snd_ssthresh,
+		Reordering:   raw.Reordering,
+
+		LastDataSent: msec(raw.Last_data_sent),
+		LastDataRecv: msec(raw.Last_data_recv),
+		LastAckRecv:  msec(raw.Last_ack_recv),
+
+		PacingRate:    raw.Pacing_rate,
+		DeliveryRate:  raw.Delivery_rate,
+		BytesSent:     raw.Bytes_sent,
+		BytesRetrans:  raw.Bytes_retrans,
+		BytesAcked:    raw.Bytes_acked,
+		BytesReceived: raw.Bytes_received,
+		SegsOut:       raw.Segs_out,
+		SegsIn:        raw.Segs_in,
+		NotSentBytes:  raw.Notsent_bytes,
+
+		BusyTime:      time.Duration(raw.Busy_time) * time.Microsecond,
+		RwndLimited:   time.Duration(raw.Rwnd_limited) * time.Microsecond,
+		SndbufLimited: time.Duration(raw.Sndbuf_limited) * time.Microsecond,
+	}
+}
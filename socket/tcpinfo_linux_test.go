@@ -0,0 +1,77 @@
+package socket
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/ppltools/utils/cmsg"
+	"golang.org/x/sys/unix"
+)
+
+func TestTCPInfo(t *testing.T) {
+	client, server := tcpPair(t)
+	msg := make([]byte, 64<<10)
+	go func() {
+		client.Write(make([]byte, len(msg)))
+	}()
+	if _, err := io.ReadFull(server, msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	info, err := TCPInfo(client)
+	if err != nil {
+		t.Fatalf("TCPInfo: %v", err)
+	}
+	if info.State != 1 || info.State.String() != "ESTABLISHED" {
+		t.Fatalf("expect: ESTABLISHED; got: %v", info.State)
+	}
+	if info.Len < 104 || info.RTT <= 0 || info.SndCwnd == 0 || info.SndMSS == 0 {
+		t.Fatalf("expect: rtt, cwnd and mss filled in; got: %s (len %d)", info, info.Len)
+	}
+	if info.BytesAcked != 0 && info.BytesAcked < uint64(len(msg)) {
+		t.Fatalf("expect: at least %d bytes acked; got: %d", len(msg), info.BytesAcked)
+	}
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	if _, err := TCPInfo(c1); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expect: %v on a pipe; got: %v", ErrUnsupported, err)
+	}
+}
+
+func TestParseTCPInfoShort(t *testing.T) {
+	raw := unix.TCPInfo{State: 1, Rtt: 1500, Snd_cwnd: 10, Delivery_rate: 1 << 20}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(&raw)), unsafe.Sizeof(raw))
+
+	// A kernel older than 4.9 stops before delivery_rate.
+	short := int(unsafe.Offsetof(raw.Delivery_rate))
+	info := parseTCPInfo(b[:short])
+	if info.Len != short || info.RTT.Microseconds() != 1500 || info.SndCwnd != 10 || info.DeliveryRate != 0 {
+		t.Fatalf("expect: rtt=1.5ms cwnd=10 delivery=0; got: %s (len %d)", info, info.Len)
+	}
+	info = parseTCPInfo(b)
+	if info.DeliveryRate != 1<<20 {
+		t.Fatalf("expect: delivery=%d; got: %d", 1<<20, info.DeliveryRate)
+	}
+	if info := parseTCPInfo(nil); info.Len != 0 || info.State != 0 {
+		t.Fatalf("expect: zero info; got: %s", info)
+	}
+}
+
+func TestLogTCPInfo(t *testing.T) {
+	client, _ := tcpPair(t)
+	var out bytes.Buffer
+	m := cmsg.NewMessenger()
+	m.NoColor = true
+	m.Stderr = &out
+	LogTCPInfo(m, client)
+	if s := out.String(); !strings.Contains(s, "tcp_info 127.0.0.1:") || !strings.Contains(s, "state=ESTABLISHED") {
+		t.Fatalf("expect: tcp_info line; got: %q", s)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package socket
+
+import (
+	"net"
+
+	"github.com/ppltools/utils/cmsg"
+)
+
+// LogTCPInfo logs the TCPInfo of conn through m at the Info level, or a
+// warning if it cannot be read. A nil m stands for cmsg.Default. Like cmsg,
+// it is not available on windows.
+func LogTCPInfo(m *cmsg.Messenger, conn net.Conn) {
+	if m == nil {
+		m = cmsg.Default
+	}
+	info, err := TCPInfo(conn)
+	if err != nil {
+		m.Warn("tcp_info %s->%s: %v", conn.LocalAddr(), conn.RemoteAddr(), err)
+		return
+	}
+	m.Info("tcp_info %s->%s: %s", conn.LocalAddr(), conn.RemoteAddr(), info)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package socket
+
+import "net"
+
+// TCPInfo returns the TCP_INFO diagnostics of conn. Linux only.
+func TCPInfo(conn net.Conn) (*Info, error) {
+	return nil, &OptionError{Option: "TCP_INFO", Err: ErrUnsupported}
+}